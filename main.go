@@ -2,183 +2,304 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
-	"sync"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/supporttools/KubeCertWatch/pkg/adminServer"
 	"github.com/supporttools/KubeCertWatch/pkg/checks"
 	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/coordinator"
+	"github.com/supporttools/KubeCertWatch/pkg/hub"
 	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/leader"
 	"github.com/supporttools/KubeCertWatch/pkg/logging"
 	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/notify"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/rules"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
 	"github.com/robfig/cron/v3"
-	"k8s.io/client-go/kubernetes"
+	corev1 "k8s.io/api/core/v1"
 )
 
 var (
-	logger            = logging.SetupLogging()
-	taskLock          sync.Mutex
-	isTaskRunning     bool
-	lastSuccessfulRun time.Time
-	clientset         *kubernetes.Clientset
+	logger = logging.SetupLogging()
 )
 
-// withRetry implements retry logic for transient failures
-func withRetry(ctx context.Context, fn func() error) error {
-	backoff := time.Second
-	for attempts := 0; attempts < 3; attempts++ {
-		if err := fn(); err != nil {
-			if attempts == 2 {
-				return err
-			}
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(backoff):
-				backoff *= 2
-				continue
-			}
-		}
-		return nil
+func main() {
+	// Load the configuration before logging anything, so that --print-config only prints the configuration
+	opts, err := config.LoadConfiguration(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Fatalf("Failed to load configuration: %v", err)
+	}
+	if opts.PrintConfig {
+		printConfig()
+		return
 	}
-	return nil
-}
-
-// isHealthy returns the health status of the service
-func isHealthy() bool {
-	taskLock.Lock()
-	defer taskLock.Unlock()
-	return !isTaskRunning || time.Since(lastSuccessfulRun) < time.Hour
-}
 
-func main() {
 	logger.Println("Starting KubeCertWatch...")
 
-	// Load and validate configuration
-	logger.Println("Loading configuration...")
-	config.LoadConfiguration()
+	// Validate configuration
 	if err := config.ValidateRequiredConfig(); err != nil {
 		logger.Fatalf("Configuration validation failed: %v", err)
 	}
 	logger.Println("Configuration loaded and validated successfully.")
 
+	// Register Prometheus metrics with the configured prefix and labels
+	cfg := config.Current()
+	if err := metrics.Register(cfg.MetricsPrefix, cfg.ClusterName, cfg.MetricsConstLabels); err != nil {
+		logger.Fatalf("Failed to register metrics: %v", err)
+	}
+
+	// Export metrics and traces over OTLP when configured
+	shutdownTelemetry, err := telemetry.Start(context.Background())
+	if err != nil {
+		logger.Fatalf("Failed to start OpenTelemetry export: %v", err)
+	}
+
 	// Connect to Kubernetes
 	logger.Println("Connecting to Kubernetes...")
-	var err error
-	clientset, err = k8s.ConnectToK8s()
+	clientset, err := k8s.ConnectToK8s()
 	if err != nil {
 		logger.Fatalf("Failed to connect to Kubernetes: %v", err)
 	}
 	logger.Println("Connected to Kubernetes successfully.")
 
+	// Load the CertWatchPolicies before the first run so that it already applies them
+	policyCtx, stopPolicies := context.WithCancel(context.Background())
+	if err := policy.Watch(policyCtx); err != nil {
+		logger.Errorf("Failed to load CertWatchPolicies: %v", err)
+	}
+
+	// Load the silences shared by all replicas; silenced objects are marked in the metrics at once
+	silences.OnChange(checks.RefreshMetrics)
+	silenceCtx, stopSilences := context.WithCancel(context.Background())
+	if err := silences.Watch(silenceCtx); err != nil {
+		logger.Errorf("Failed to load silences: %v", err)
+	}
+
+	// Campaign for leadership so that only one replica runs scheduled checks
+	leaderCtx, stopLeaderElection := context.WithCancel(context.Background())
+	if cfg.LeaderElection {
+		err = leader.Start(leaderCtx, clientset, func() {
+			logger.Println("Running checks after acquiring leadership...")
+			syncPrometheusRule()
+			runChecks()
+		})
+		if err != nil {
+			logger.Fatalf("Failed to start leader election: %v", err)
+		}
+	}
+
 	// Start HTTP server
 	logger.Println("Starting HTTP server...")
-	server := adminServer.StartHTTPServer(clientset)
+	server := adminServer.StartHTTPServer()
 
 	// Setup Cron Scheduler
 	logger.Println("Setting up cron scheduler...")
 	c := cron.New()
-	_, err = c.AddFunc(config.CFG.CronSchedule, func() {
-		logger.Println("Running scheduled tasks...")
-		runChecks(context.Background())
-	})
+	checkEntry, err := c.AddFunc(cfg.CronSchedule, runScheduledChecks)
 	if err != nil {
 		logger.Fatalf("Failed to schedule cron job: %v", err)
 	}
+	scheduleHeldNotifications(c)
+	// Unmark silenced objects in the metrics soon after their silence expires
+	if _, err := c.AddFunc("@every 1m", checks.RefreshMetrics); err != nil {
+		logger.Fatalf("Failed to schedule refresh of the metrics: %v", err)
+	}
+	if cfg.LeaderElection {
+		if _, err := c.AddFunc("@every 1m", leader.Replicate); err != nil {
+			logger.Fatalf("Failed to schedule replication from the leader: %v", err)
+		}
+	}
+	if cfg.HubEnabled {
+		logger.Println("Hub mode enabled. Accepting agent reports...")
+		if _, err := c.AddFunc("@every 1m", hub.CheckAgents); err != nil {
+			logger.Fatalf("Failed to schedule agent liveness check: %v", err)
+		}
+	}
 	c.Start()
 
+	// Run the checks once at startup so that /readyz does not wait for the first cron tick. With leader
+	// election, the initial run starts when the Lease is acquired.
+	if !cfg.LeaderElection {
+		go syncPrometheusRule()
+		logger.Println("Running initial checks...")
+		go runChecks()
+	}
+
+	// Reload the configuration on SIGHUP and whenever the config file changes
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	fileChanged := make(chan struct{}, 1)
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	go config.WatchFile(watchCtx, func() {
+		select {
+		case fileChanged <- struct{}{}:
+		default:
+		}
+	})
+
 	// Graceful Shutdown
 	logger.Println("Setting up signal handling for graceful shutdown...")
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	<-stop
+	for running := true; running; {
+		select {
+		case <-hangup:
+			checkEntry = reloadConfig(c, checkEntry, "SIGHUP")
+		case <-fileChanged:
+			checkEntry = reloadConfig(c, checkEntry, "config file change")
+		case <-stop:
+			running = false
+		}
+	}
 	logger.Println("Received shutdown signal. Shutting down gracefully...")
+	stopWatching()
 	c.Stop()
+	stopLeaderElection()
+	stopPolicies()
+	stopSilences()
 	if err := server.Shutdown(context.Background()); err != nil {
 		logger.Printf("Error during server shutdown: %v", err)
 	}
+	telemetryCtx, cancelTelemetry := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTelemetry()
+	if err := shutdownTelemetry(telemetryCtx); err != nil {
+		logger.Printf("Error flushing OpenTelemetry data: %v", err)
+	}
 	logger.Println("Shutdown complete.")
 }
 
-// runChecks performs all scheduled checks
-func runChecks(ctx context.Context) {
-	taskLock.Lock()
-	if isTaskRunning {
-		logger.Println("Checks already running. Skipping this cycle.")
-		taskLock.Unlock()
+// syncPrometheusRule publishes the generated alerting rules as a PrometheusRule when enabled
+func syncPrometheusRule() {
+	if !config.Current().PrometheusRuleEnabled {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := rules.SyncPrometheusRule(ctx); err != nil {
+		logger.Errorf("Failed to sync PrometheusRule: %v", err)
+	}
+}
+
+// runScheduledChecks runs the checks on the cron schedule while this instance is the leader
+func runScheduledChecks() {
+	if !leader.IsLeader() {
+		logger.Println("Not the leader. Skipping scheduled tasks.")
 		return
 	}
-	isTaskRunning = true
-	taskLock.Unlock()
+	logger.Println("Running scheduled tasks...")
+	runChecks()
+}
+
+// releaseHeldNotifications delivers the notifications held during quiet windows while this instance is
+// the leader
+func releaseHeldNotifications() {
+	if !leader.IsLeader() {
+		return
+	}
+	notify.ReleaseHeld()
+}
 
-	defer func() {
-		taskLock.Lock()
-		isTaskRunning = false
-		taskLock.Unlock()
-	}()
+// heldScheduled records whether the notifications held during quiet windows are being delivered.
+var heldScheduled bool
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+// scheduleHeldNotifications stores the notifications held during quiet windows and schedules their
+// delivery once the routes have quiet windows. Until then nothing can be held, so neither is needed.
+func scheduleHeldNotifications(c *cron.Cron) {
+	if heldScheduled || !notify.HasQuietWindows(config.Current().Route) {
+		return
+	}
+	// Keep the held notifications across restarts and leader changes
+	if err := notify.StoreHeld(); err != nil {
+		logger.Errorf("Failed to store held notifications, keeping them in memory: %v", err)
+	}
+	if _, err := c.AddFunc("@every 1m", releaseHeldNotifications); err != nil {
+		logger.Errorf("Failed to schedule delivery of held notifications: %v", err)
+		return
+	}
+	heldScheduled = true
+}
+
+// reloadConfig activates a changed configuration and moves the scheduled checks to its cron schedule.
+// An invalid configuration is rejected with a Warning event and the current one stays active. It returns
+// the cron entry of the scheduled checks.
+func reloadConfig(c *cron.Cron, checkEntry cron.EntryID, trigger string) cron.EntryID {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var wg sync.WaitGroup
-	errChan := make(chan error, 2)
-
-	// Run checks in parallel
-	wg.Add(2)
-	
-	// TLS secret checks
-	go func() {
-		defer wg.Done()
-		logger.Println("Running TLS secret checks...")
-		err := withRetry(ctx, func() error {
-			return checks.CheckTLSSecrets(ctx, clientset)
-		})
-		if err != nil {
-			logger.Errorf("Failed to check TLS secrets: %v", err)
-			metrics.ErrorCounter.WithLabelValues("tls-secrets", "check_error").Inc()
-			errChan <- fmt.Errorf("TLS secrets check: %w", err)
-		}
-		metrics.LastCheckTime.WithLabelValues("tls-secret-check").SetToCurrentTime()
-	}()
-
-	// cert-manager checks
-	go func() {
-		defer wg.Done()
-		logger.Println("Running cert-manager certificate checks...")
-		err := withRetry(ctx, func() error {
-			return checks.CheckCertManagerCertificates(ctx, config.CFG.KubeConfig)
-		})
+	logger.Printf("Reloading configuration after %s...", trigger)
+	previous := config.Current()
+	pinned, err := config.Reload()
+	if err != nil {
+		logger.Errorf("Keeping the current configuration, the reloaded one is invalid: %v", err)
+		metrics.ErrorCounter.WithLabelValues(previous.ClusterName, "config", "reload_error").Inc()
+		k8s.RecordEvent(ctx, corev1.EventTypeWarning, "ConfigReloadFailed",
+			fmt.Sprintf("Kept the current configuration after %s: %v", trigger, err))
+		return checkEntry
+	}
+
+	message := fmt.Sprintf("Configuration reloaded after %s", trigger)
+	if len(pinned) > 0 {
+		logger.Warnf("Settings %s only take effect after a restart", strings.Join(pinned, ", "))
+		message += fmt.Sprintf("; %s only take effect after a restart", strings.Join(pinned, ", "))
+	}
+
+	current := config.Current()
+	if current.CronSchedule != previous.CronSchedule {
+		entry, err := c.AddFunc(current.CronSchedule, runScheduledChecks)
 		if err != nil {
-			logger.Errorf("Failed to check cert-manager certificates: %v", err)
-			metrics.ErrorCounter.WithLabelValues("cert-manager", "check_error").Inc()
-			errChan <- fmt.Errorf("cert-manager check: %w", err)
+			logger.Errorf("Failed to reschedule checks to %q: %v", current.CronSchedule, err)
+		} else {
+			c.Remove(checkEntry)
+			checkEntry = entry
+			logger.Printf("Rescheduled checks from %q to %q.", previous.CronSchedule, current.CronSchedule)
 		}
-		metrics.LastCheckTime.WithLabelValues("cert-manager-check").SetToCurrentTime()
-	}()
-
-	// Wait for all checks to complete
-	wg.Wait()
-	close(errChan)
-
-	// Process any errors
-	errCount := 0
-	for err := range errChan {
-		errCount++
-		logger.Error(err)
-	}
-
-	if errCount == 0 {
-		taskLock.Lock()
-		lastSuccessfulRun = time.Now()
-		taskLock.Unlock()
-		logger.Println("Certificate checks completed successfully.")
-	} else {
-		logger.Printf("Certificate checks completed with %d errors.", errCount)
+	}
+
+	// Quiet windows may have been added to the routes
+	scheduleHeldNotifications(c)
+
+	// The generated rules depend on the expiry threshold and the schedule
+	if leader.IsLeader() {
+		go syncPrometheusRule()
+	}
+
+	logger.Println(message + ".")
+	k8s.RecordEvent(ctx, corev1.EventTypeNormal, "ConfigReloaded", message)
+	return checkEntry
+}
+
+// runChecks starts a scheduled run of all checks and waits for it to complete
+func runChecks() {
+	run, err := coordinator.Start(coordinator.TriggerSchedule, coordinator.ScheduledChecks(), true)
+	if err != nil {
+		logger.Printf("Checks already running. Skipping this cycle: %v", err)
+		return
+	}
+	<-run.Done()
+}
+
+// printConfig prints the effective configuration followed by any validation errors, and exits non-zero
+// when it is invalid.
+func printConfig() {
+	if err := config.PrintConfig(os.Stdout); err != nil {
+		logger.Fatalf("Failed to print configuration: %v", err)
+	}
+	if err := config.ValidateRequiredConfig(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration is invalid:\n%v\n", err)
+		os.Exit(1)
 	}
 }
@@ -1,38 +1,37 @@
 package adminServer
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 
-	"github.com/supporttools/KubeCertWatch/pkg/checks"
 	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/coordinator"
+	"github.com/supporttools/KubeCertWatch/pkg/hub"
+	"github.com/supporttools/KubeCertWatch/pkg/leader"
 	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
 	"github.com/supporttools/KubeCertWatch/pkg/pages"
+	"github.com/supporttools/KubeCertWatch/pkg/rules"
 	"github.com/supporttools/KubeCertWatch/pkg/version"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
 )
 
 var (
-	log           = logging.SetupLogging()
-	taskLock      sync.Mutex
-	isTaskRunning bool
+	log = logging.SetupLogging()
 )
 
 // StartHTTPServer starts an HTTP server for metrics and admin endpoints
-func StartHTTPServer(clientset *kubernetes.Clientset) *http.Server {
+func StartHTTPServer() *http.Server {
 	log.Println("Setting up HTTP server...")
 	mux := http.NewServeMux()
 
 	// Register routes
-	registerRoutes(mux, clientset)
+	registerRoutes(mux)
 
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", config.CFG.MetricsPort),
+		Addr:         fmt.Sprintf(":%d", config.Current().MetricsPort),
 		Handler:      logRequestMiddleware(mux),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
@@ -40,7 +39,7 @@ func StartHTTPServer(clientset *kubernetes.Clientset) *http.Server {
 	}
 
 	go func() {
-		log.Printf("HTTP server running on port %d", config.CFG.MetricsPort)
+		log.Printf("HTTP server running on port %d", config.Current().MetricsPort)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("HTTP server failed: %v", err)
 		}
@@ -49,59 +48,45 @@ func StartHTTPServer(clientset *kubernetes.Clientset) *http.Server {
 }
 
 // registerRoutes registers all HTTP routes
-func registerRoutes(mux *http.ServeMux, clientset *kubernetes.Clientset) {
+func registerRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/", pages.DefaultPage)
-	mux.Handle("/metrics", promhttp.Handler())
-	mux.HandleFunc("/healthz", healthCheck)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/healthz", livenessCheck)
+	mux.HandleFunc("/livez", livenessCheck)
+	mux.HandleFunc("/readyz", readinessCheck)
 	mux.HandleFunc("/version", versionInfo)
+	mux.HandleFunc("/prometheus/rules", prometheusRules)
 
-	// TLS Secret Check Handler
-	mux.HandleFunc("/check/secrets", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("HTTP request to /check/secrets from %s", r.RemoteAddr)
-		if !triggerTask("secrets", clientset) {
-			http.Error(w, "Task already running", http.StatusConflict)
-			return
-		}
-		w.WriteHeader(http.StatusAccepted)
-		fmt.Fprint(w, "TLS secrets check initiated.")
-	})
+	// Check Handlers
+	for _, check := range coordinator.Checks() {
+		mux.HandleFunc("/check/"+check.Name, checkHandler(check))
+	}
 
-	// Cert-Manager Check Handler
-	mux.HandleFunc("/check/cert-manager", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("HTTP request to /check/cert-manager from %s", r.RemoteAddr)
-		if !triggerTask("cert-manager", clientset) {
-			http.Error(w, "Task already running", http.StatusConflict)
-			return
-		}
-		w.WriteHeader(http.StatusAccepted)
-		fmt.Fprint(w, "cert-manager check initiated.")
-	})
+	// Run API
+	mux.HandleFunc("/api/v1/runs", runsHandler)
+	mux.HandleFunc("/api/v1/runs/{id}", runHandler)
 
-	// Ingress Check Handler
-	mux.HandleFunc("/check/ingress", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("HTTP request to /check/ingress from %s", r.RemoteAddr)
-		if !triggerTask("ingress", clientset) {
-			http.Error(w, "Task already running", http.StatusConflict)
-			return
-		}
-		w.WriteHeader(http.StatusAccepted)
-		fmt.Fprint(w, "Ingress check initiated.")
-	})
+	// Silence API
+	mux.HandleFunc("/api/v1/silences", silencesHandler)
+	mux.HandleFunc("/api/v1/silences/{id}", silenceHandler)
+
+	// Snapshot API replicated by followers
+	mux.HandleFunc(leader.SnapshotPath, snapshotHandler)
 
 	// Status Pages
 	mux.HandleFunc("/status/secrets", pages.SecretsStatusPage)
 	mux.HandleFunc("/status/cert-manager", pages.CertManagerStatusPage)
 	mux.HandleFunc("/status/ingress", pages.IngressStatusPage)
-}
-
-// healthCheck returns a JSON response indicating system health
-func healthCheck(w http.ResponseWriter, _ *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	err := json.NewEncoder(w).Encode(map[string]string{"status": "healthy"})
-	if err != nil {
-		log.Printf("Failed to write health check response: %v", err)
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	mux.HandleFunc("/status/webhooks", pages.WebhookStatusPage)
+	mux.HandleFunc("/status/gateways", pages.GatewayStatusPage)
+	mux.HandleFunc("/status/routes", pages.RouteStatusPage)
+	mux.HandleFunc("/status/services", pages.ServiceStatusPage)
+	mux.HandleFunc("/status/external", pages.ExternalStatusPage)
+	mux.HandleFunc("/status/fleet", pages.FleetStatusPage)
+
+	// Hub endpoint receiving agent reports
+	if config.Current().HubEnabled {
+		mux.HandleFunc(hub.PushPath, hub.PushHandler)
 	}
 }
 
@@ -119,6 +104,18 @@ func versionInfo(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// prometheusRules returns the recommended recording and alerting rules as a Prometheus rule file
+func prometheusRules(w http.ResponseWriter, _ *http.Request) {
+	data, err := yaml.Marshal(rules.Generate())
+	if err != nil {
+		log.Printf("Failed to encode Prometheus rules: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(data)
+}
+
 // logRequestMiddleware logs incoming HTTP requests
 func logRequestMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -127,52 +124,28 @@ func logRequestMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// triggerTask ensures only one task runs at a time
-func triggerTask(task string, clientset *kubernetes.Clientset) bool {
-	taskLock.Lock()
-	defer taskLock.Unlock()
-
-	if isTaskRunning {
-		log.Printf("Task already running; skipping %s request.", task)
-		return false
-	}
-
-	isTaskRunning = true
-	go func() {
-		defer func() {
-			taskLock.Lock()
-			isTaskRunning = false
-			taskLock.Unlock()
-			log.Printf("Task %s completed.", task)
-		}()
-		runTask(task, clientset)
-	}()
-
-	return true
-}
-
-// runTask executes the specified task
-func runTask(task string, clientset *kubernetes.Clientset) {
-	switch task {
-	case "secrets":
-		log.Println("Starting TLS secret check...")
-		err := checks.CheckTLSSecrets(context.Background(), clientset)
+// checkHandler starts a manual run of check through the run coordinator. With the wait parameter, the
+// request blocks until the run completes and returns its status.
+func checkHandler(check coordinator.Check) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("HTTP request to /check/%s from %s", check.Name, r.RemoteAddr)
+		wait, err := parseWait(r)
 		if err != nil {
-			log.Errorf("Error during TLS secret check: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	case "cert-manager":
-		log.Println("Starting cert-manager certificate check...")
-		err := checks.CheckCertManagerCertificates(context.Background(), config.CFG.KubeConfig)
+		run, err := coordinator.Start(coordinator.TriggerManual, []string{check.Name}, false)
 		if err != nil {
-			log.Errorf("Error during cert-manager certificate check: %v", err)
+			log.Printf("Skipping %s request: %v", check.Name, err)
+			http.Error(w, "Task already running", http.StatusConflict)
+			return
 		}
-	case "ingress":
-		log.Println("Starting Ingress check...")
-		err := checks.CheckIngress(context.Background(), clientset)
-		if err != nil {
-			log.Errorf("Error during Ingress check: %v", err)
+		if wait > 0 {
+			writeRun(w, r, run, wait)
+			return
 		}
-	default:
-		log.Printf("Invalid task specified: %s", task)
+		w.Header().Set("Location", "/api/v1/runs/"+run.ID)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "%s initiated. Run ID: %s", check.Description, run.ID)
 	}
 }
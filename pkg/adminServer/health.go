@@ -0,0 +1,111 @@
+package adminServer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/coordinator"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/leader"
+)
+
+// pingTimeout bounds the API server request made by the readiness check.
+const pingTimeout = 5 * time.Second
+
+// Condition is one of the criteria evaluated by the readiness check.
+type Condition struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// livenessCheck reports whether the process is alive. It only fails when a run is stuck, since
+// restarting the pod is the only way to release its checks.
+func livenessCheck(w http.ResponseWriter, _ *http.Request) {
+	status, code := "healthy", http.StatusOK
+	body := map[string]interface{}{"leaderElection": leader.GetStatus()}
+	if run, stalled := coordinator.StalledRun(); stalled {
+		status, code = "unhealthy", http.StatusServiceUnavailable
+		body["message"] = fmt.Sprintf("run %s has been running since %s", run.ID, run.StartTime.Format(time.RFC3339))
+	}
+	body["status"] = status
+	writeJSON(w, code, body)
+}
+
+// readinessCheck reports whether the instance serves up-to-date results: the API server must be
+// reachable and, on the instance running scheduled checks, every scheduled check must have run and
+// succeeded in the local cluster within the readiness window. Failures in remote clusters do not
+// affect readiness.
+func readinessCheck(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), pingTimeout)
+	defer cancel()
+
+	maxAge := coordinator.MaxRunAge()
+	states := coordinator.GetCheckStates()
+	conditions := []Condition{apiServerCondition(ctx)}
+	if leader.IsLeader() {
+		conditions = append(conditions, initialRunCondition(states), freshnessCondition(states, maxAge))
+	} else {
+		conditions = append(conditions, Condition{Name: "scheduledRuns", OK: true, Message: "standby; scheduled checks are run by the leader"})
+	}
+
+	status, code := "ready", http.StatusOK
+	for _, condition := range conditions {
+		if !condition.OK {
+			status, code = "not ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+	writeJSON(w, code, map[string]interface{}{
+		"status":         status,
+		"conditions":     conditions,
+		"maxAge":         maxAge.String(),
+		"checks":         states,
+		"leaderElection": leader.GetStatus(),
+	})
+}
+
+// apiServerCondition verifies that the API server of the local cluster is reachable.
+func apiServerCondition(ctx context.Context) Condition {
+	if err := k8s.Ping(ctx); err != nil {
+		return Condition{Name: "apiServer", Message: err.Error()}
+	}
+	return Condition{Name: "apiServer", OK: true}
+}
+
+// initialRunCondition verifies that every scheduled check has completed at least one run.
+func initialRunCondition(states []coordinator.CheckState) Condition {
+	var pending []string
+	for _, state := range states {
+		if state.Scheduled && state.LastRunTime == nil {
+			pending = append(pending, state.Check)
+		}
+	}
+	if len(pending) > 0 {
+		return Condition{Name: "initialRun", Message: "no completed run yet: " + strings.Join(pending, ", ")}
+	}
+	return Condition{Name: "initialRun", OK: true}
+}
+
+// freshnessCondition verifies that every scheduled check that has run has succeeded in the local
+// cluster within maxAge.
+func freshnessCondition(states []coordinator.CheckState, maxAge time.Duration) Condition {
+	var stale []string
+	for _, state := range states {
+		if !state.Scheduled || state.LastRunTime == nil {
+			continue
+		}
+		if state.LastLocalSuccess == nil {
+			stale = append(stale, state.Check+" (never succeeded)")
+		} else if time.Since(*state.LastLocalSuccess) > maxAge {
+			stale = append(stale, fmt.Sprintf("%s (last success %s)", state.Check, state.LastLocalSuccess.Format(time.RFC3339)))
+		}
+	}
+	if len(stale) > 0 {
+		return Condition{Name: "freshness", Message: "last success in the local cluster older than " + maxAge.String() + ": " + strings.Join(stale, ", ")}
+	}
+	return Condition{Name: "freshness", OK: true}
+}
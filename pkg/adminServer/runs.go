@@ -0,0 +1,101 @@
+package adminServer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/coordinator"
+)
+
+// maxWait bounds how long a request can wait for a run to complete.
+const maxWait = 10 * time.Minute
+
+// runsHandler lists the most recent runs, newest first
+func runsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, coordinator.GetRuns())
+}
+
+// runHandler returns a single run, optionally waiting for it to complete
+func runHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	wait, err := parseWait(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	run, found := coordinator.GetRun(r.PathValue("id"))
+	if !found {
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+	writeRun(w, r, run, wait)
+}
+
+// parseWait reads the wait query parameter, which is either a boolean or a duration such as 90s.
+// A true value waits up to maxWait.
+func parseWait(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("wait")
+	if value == "" {
+		return 0, nil
+	}
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		if enabled {
+			return maxWait, nil
+		}
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(value)
+	if err != nil || wait < 0 {
+		return 0, fmt.Errorf("invalid wait %q: expected a boolean or a duration such as 90s", value)
+	}
+	if wait > maxWait {
+		wait = maxWait
+	}
+	return wait, nil
+}
+
+// writeRun waits up to wait for run to complete and writes its status. Completed runs are returned
+// with 200, runs still in progress with 202.
+func writeRun(w http.ResponseWriter, r *http.Request, run *coordinator.Run, wait time.Duration) {
+	if wait > 0 {
+		// Waiting outlives the server's write timeout
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second)); err != nil {
+			log.Printf("Failed to extend write deadline for run %s: %v", run.ID, err)
+		}
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-run.Done():
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	status := run.Status()
+	code := http.StatusOK
+	if status.State == coordinator.StateRunning {
+		code = http.StatusAccepted
+	}
+	w.Header().Set("Location", "/api/v1/runs/"+run.ID)
+	writeJSON(w, code, status)
+}
+
+// writeJSON writes value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Failed to encode JSON response: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package adminServer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+)
+
+// maxSilenceSize bounds the size of a silence submitted through the API.
+const maxSilenceSize = 64 << 10
+
+// silencesHandler lists the silences on GET and creates a silence on POST
+func silencesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, silences.List())
+	case http.MethodPost:
+		var silence silences.Silence
+		decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSilenceSize))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&silence); err != nil {
+			http.Error(w, "Invalid silence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := silence.Validate(); err != nil {
+			http.Error(w, "Invalid silence: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		created, err := silences.Create(r.Context(), silence)
+		if err != nil {
+			writeSilenceError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/api/v1/silences/"+created.ID)
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// silenceHandler expires a silence on DELETE
+func silenceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	expired, err := silences.Expire(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeSilenceError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, expired)
+}
+
+// writeSilenceError maps errors of the silence store to status codes
+func writeSilenceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, silences.ErrNotFound):
+		http.Error(w, "Silence not found", http.StatusNotFound)
+	case errors.Is(err, silences.ErrUnavailable):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		log.Printf("Failed to store silences: %v", err)
+		http.Error(w, "Failed to store silences", http.StatusInternalServerError)
+	}
+}
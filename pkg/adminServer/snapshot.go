@@ -0,0 +1,16 @@
+package adminServer
+
+import (
+	"net/http"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+)
+
+// snapshotHandler returns the statuses of every cluster, which followers replicate from the leader
+func snapshotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, checks.TakeSnapshot(checks.Clusters()))
+}
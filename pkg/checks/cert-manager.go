@@ -2,13 +2,17 @@ package checks
 
 import (
 	"context"
+	"time"
 
 	certmanagerv1 "github.com/cert-manager/cert-manager/pkg/apis/certmanager/v1"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/dynamic"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 )
 
 var (
@@ -17,10 +21,19 @@ var (
 
 // CertManagerStatus represents the status of a cert-manager certificate
 type CertManagerStatus struct {
-	Namespace      string
-	Certificate    string
-	RenewalFailure string
-	Status         string
+	Cluster           string
+	Namespace         string
+	Certificate       string
+	RenewalFailure    string
+	NotAfter          time.Time
+	Status            string
+	Subject           string
+	Issuer            string
+	Chain             string
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
 }
 
 // GetCertManagerStatuses returns the current statuses of cert-manager certificates
@@ -31,26 +44,9 @@ func GetCertManagerStatuses() []CertManagerStatus {
 }
 
 // CheckCertManagerCertificates scans for certificates managed by cert-manager and checks their renewal status.
-func CheckCertManagerCertificates(ctx context.Context, kubeConfigPath string) error {
-	var kubeConfig *rest.Config
-	var err error
-
-	// Connect to the cluster
-	if kubeConfigPath != "" {
-		kubeConfig, err = clientcmd.BuildConfigFromFlags("", kubeConfigPath)
-	} else {
-		kubeConfig, err = rest.InClusterConfig()
-	}
-
-	if err != nil {
-		log.Errorf("Failed to configure Kubernetes client: %v", err)
-		return err
-	}
-
-	// Create a dynamic client
-	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
+func CheckCertManagerCertificates(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	dynamicClient, err := cluster.DynamicClient()
 	if err != nil {
-		log.Errorf("Failed to create dynamic client: %v", err)
 		return err
 	}
 
@@ -58,13 +54,21 @@ func CheckCertManagerCertificates(ctx context.Context, kubeConfigPath string) er
 	certificateGVR := certmanagerv1.SchemeGroupVersion.WithResource("certificates")
 
 	// List all Certificate resources in all namespaces
-	certList, err := dynamicClient.Resource(certificateGVR).Namespace("").List(ctx, metav1.ListOptions{})
+	listCtx, span := startListSpan(ctx, cluster.Name, "certificates.cert-manager.io")
+	certList, err := dynamicClient.Resource(certificateGVR).Namespace("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
 	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Println("cert-manager is not installed in the cluster. Skipping Certificate checks.")
+			return nil
+		}
 		log.Errorf("Failed to list cert-manager Certificates: %v", err)
 		return err
 	}
 
-	certManagerStatuses = []CertManagerStatus{} // Clear previous statuses
+	statuses := []CertManagerStatus{}
+	owners := newOwnerResolver(ctx, cluster)
+	issued := tlsSecretCertificates(ctx, cluster)
 
 	// Iterate through Certificates and check conditions
 	for _, cert := range certList.Items {
@@ -86,13 +90,58 @@ func CheckCertManagerCertificates(ctx context.Context, kubeConfigPath string) er
 			}
 		}
 
-		certManagerStatuses = append(certManagerStatuses, CertManagerStatus{
-			Namespace:      certObj.Namespace,
-			Certificate:    certObj.Name,
-			RenewalFailure: renewalFailure,
-			Status:         status,
+		var notAfter time.Time
+		if certObj.Status.NotAfter != nil {
+			notAfter = certObj.Status.NotAfter.Time
+		}
+
+		// The subject, issuer and chain are read from the Secret the Certificate is issued to
+		var subject, issuer, chain string
+		if certPEM, ok := issued[certObj.Namespace+"/"+certObj.Spec.SecretName]; ok {
+			if certs, err := parseCertificates(ctx, certPEM); err == nil {
+				subject, issuer, chain = nameOf(certs[0].Subject), nameOf(certs[0].Issuer), describeChain(certs)
+			}
+		}
+
+		p := policy.Resolve(policy.Object{Kind: "Certificate", Namespace: certObj.Namespace, Name: certObj.Name, Labels: certObj.Labels})
+		statuses = append(statuses, CertManagerStatus{
+			Cluster:           cluster.Name,
+			Namespace:         certObj.Namespace,
+			Certificate:       certObj.Name,
+			RenewalFailure:    renewalFailure,
+			NotAfter:          notAfter,
+			Status:            status,
+			Subject:           subject,
+			Issuer:            issuer,
+			Chain:             chain,
+			Owner:             owners.resolve(certObj.Namespace, certObj.Labels, certObj.Annotations),
+			Policy:            p.Name,
+			Muted:             p.Muted,
+			AnnotationSilence: annotationSilence("Certificate", certObj.Namespace, certObj.Name, certObj.Annotations),
 		})
 	}
 
+	record(results, &results.certManager, cluster.Name, statuses)
+
 	return nil
 }
+
+// tlsSecretCertificates returns the tls.crt of every TLS secret in cluster, keyed by namespace/name.
+// Failures are logged and return no certificates.
+func tlsSecretCertificates(ctx context.Context, cluster k8s.Cluster) map[string][]byte {
+	certificates := map[string][]byte{}
+	if cluster.Clientset == nil {
+		return certificates
+	}
+	listCtx, span := startListSpan(ctx, cluster.Name, "secrets")
+	secrets, err := cluster.Clientset.CoreV1().Secrets("").List(listCtx, metav1.ListOptions{FieldSelector: "type=" + string(corev1.SecretTypeTLS)})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Warnf("Failed to list TLS secrets in cluster %s, Certificates are reported without subject and issuer: %v", cluster.Name, err)
+		return certificates
+	}
+	for _, secret := range secrets.Items {
+		certificates[secret.Namespace+"/"+secret.Name] = secret.Data["tls.crt"]
+	}
+	return certificates
+}
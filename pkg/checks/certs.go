@@ -0,0 +1,92 @@
+package checks
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// parseCertificates decodes every PEM "CERTIFICATE" block in data.
+func parseCertificates(ctx context.Context, data []byte) (certs []*x509.Certificate, err error) {
+	_, span := telemetry.StartSpan(ctx, "Parse certificates")
+	defer func() {
+		span.SetAttributes(attribute.Int("certificates", len(certs)))
+		telemetry.EndSpan(span, err)
+	}()
+
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// nameOf returns the common name of name, or the full distinguished name when it has none.
+func nameOf(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+	return name.String()
+}
+
+// describeChain renders the subjects of a certificate chain from leaf to root.
+func describeChain(chain []*x509.Certificate) string {
+	subjects := make([]string, 0, len(chain))
+	for _, cert := range chain {
+		subjects = append(subjects, nameOf(cert.Subject))
+	}
+	return strings.Join(subjects, " <- ")
+}
+
+// expiryStatus returns the whole days until notAfter (negative once expired) and the matching status
+// under the thresholds of the object's policy.
+func expiryStatus(notAfter time.Time, p policy.Resolved) (int, string) {
+	if time.Now().After(notAfter) {
+		return int(time.Since(notAfter).Hours()/24) * -1, "expired"
+	}
+
+	daysUntil := int(time.Until(notAfter).Hours() / 24)
+	if daysUntil < p.CriticalDays {
+		return daysUntil, "critical"
+	}
+	if daysUntil < p.WarningDays {
+		return daysUntil, "expiring soon"
+	}
+	return daysUntil, "valid"
+}
+
+// coversHostname reports whether cert is valid for host, including wildcard hostnames such as "*.example.com".
+func coversHostname(cert *x509.Certificate, host string) bool {
+	if strings.HasPrefix(host, "*.") {
+		for _, name := range cert.DNSNames {
+			if strings.EqualFold(name, host) {
+				return true
+			}
+		}
+		return false
+	}
+	return cert.VerifyHostname(host) == nil
+}
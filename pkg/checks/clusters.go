@@ -0,0 +1,92 @@
+package checks
+
+import "sort"
+
+// clusterScoped is implemented by status records that belong to a monitored cluster.
+type clusterScoped interface {
+	clusterName() string
+}
+
+func (s SecretStatus) clusterName() string      { return s.Cluster }
+func (s CertManagerStatus) clusterName() string { return s.Cluster }
+func (s IngressStatus) clusterName() string     { return s.Cluster }
+func (s WebhookStatus) clusterName() string     { return s.Cluster }
+func (s GatewayStatus) clusterName() string     { return s.Cluster }
+func (s RouteStatus) clusterName() string       { return s.Cluster }
+func (s ProbeStatus) clusterName() string       { return s.Cluster }
+
+// mergeClusterStatuses replaces the records of cluster in current with updated, keeping the records of
+// other clusters so that concurrent per-cluster runs do not overwrite each other.
+func mergeClusterStatuses[T clusterScoped](current []T, cluster string, updated []T) []T {
+	merged := make([]T, 0, len(current)+len(updated))
+	for _, status := range current {
+		if status.clusterName() != cluster {
+			merged = append(merged, status)
+		}
+	}
+	return append(merged, updated...)
+}
+
+// retainClusterStatuses drops the records of clusters that are not in clusters.
+func retainClusterStatuses[T clusterScoped](current []T, clusters map[string]bool) []T {
+	retained := make([]T, 0, len(current))
+	for _, status := range current {
+		if clusters[status.clusterName()] {
+			retained = append(retained, status)
+		}
+	}
+	return retained
+}
+
+// retainClusters drops the statuses of clusters that are not in clusters. statusLock must be held.
+func retainClusters(clusters map[string]bool) {
+	secretStatuses = retainClusterStatuses(secretStatuses, clusters)
+	certManagerStatuses = retainClusterStatuses(certManagerStatuses, clusters)
+	ingressStatus = retainClusterStatuses(ingressStatus, clusters)
+	webhookStatuses = retainClusterStatuses(webhookStatuses, clusters)
+	gatewayStatuses = retainClusterStatuses(gatewayStatuses, clusters)
+	routeStatuses = retainClusterStatuses(routeStatuses, clusters)
+	serviceStatuses = retainClusterStatuses(serviceStatuses, clusters)
+	externalStatuses = retainClusterStatuses(externalStatuses, clusters)
+}
+
+// Clusters returns the names of the clusters that have published statuses.
+func Clusters() []string {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	seen := map[string]bool{}
+	var names []string
+	add := func(cluster string) {
+		if !seen[cluster] {
+			seen[cluster] = true
+			names = append(names, cluster)
+		}
+	}
+	for _, s := range secretStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range certManagerStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range ingressStatus {
+		add(s.Cluster)
+	}
+	for _, s := range webhookStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range gatewayStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range routeStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range serviceStatuses {
+		add(s.Cluster)
+	}
+	for _, s := range externalStatuses {
+		add(s.Cluster)
+	}
+	sort.Strings(names)
+	return names
+}
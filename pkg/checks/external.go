@@ -0,0 +1,54 @@
+package checks
+
+import (
+	"context"
+	"strings"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+)
+
+var (
+	externalStatuses []ProbeStatus
+)
+
+// GetExternalStatuses returns a snapshot of the current external endpoint statuses.
+func GetExternalStatuses() []ProbeStatus {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	// Return a copy to avoid concurrent modification issues
+	statusCopy := make([]ProbeStatus, len(externalStatuses))
+	copy(statusCopy, externalStatuses)
+	return statusCopy
+}
+
+// CheckExternalEndpoints probes the statically configured TLS endpoints outside the cluster.
+func CheckExternalEndpoints(ctx context.Context, targets []config.ProbeTarget, results *Results) error {
+	log.Println("Starting external endpoint probes...")
+
+	statuses := make([]ProbeStatus, 0, len(targets))
+	for _, target := range targets {
+		serverName := target.SNI
+		if serverName == "" {
+			serverName = hostOf(target.Address)
+		}
+
+		p := policy.Resolve(policy.Object{Kind: "External", Name: target.Address})
+		status := probeEndpoint(ctx, config.Current().ClusterName, "", target.Address, target.Address, serverName, target.Protocol, p)
+		status.Owner = target.Owner
+		if status.Status != "unreachable" && target.ExpectedIssuer != "" &&
+			!strings.Contains(strings.ToLower(status.Issuer), strings.ToLower(target.ExpectedIssuer)) {
+			log.Warnf("External endpoint %s is issued by %q, expected %q", target.Address, status.Issuer, target.ExpectedIssuer)
+			status.Status = "unexpected issuer"
+		}
+		log.Debugf("External endpoint: %s, Issuer: %s, Status: %s", target.Address, status.Issuer, status.Status)
+
+		statuses = append(statuses, status)
+	}
+
+	record(results, &results.external, config.Current().ClusterName, statuses)
+
+	log.Println("External endpoint probes completed.")
+	return nil
+}
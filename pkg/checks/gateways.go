@@ -0,0 +1,246 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+// GatewayStatus represents the status of a certificate referenced by a Gateway listener.
+type GatewayStatus struct {
+	Cluster           string
+	Namespace         string
+	Gateway           string
+	Listener          string
+	Hostname          string
+	SecretRef         string
+	Subject           string
+	Issuer            string
+	Chain             string
+	ExpirationDate    string
+	NotAfter          time.Time
+	DaysUntil         int
+	HostnameCovered   string
+	Status            string
+	ProbeStatus       string
+	ProbeResults      map[string]bool // address -> whether the SSL probe succeeded
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
+}
+
+var (
+	gatewayStatuses []GatewayStatus
+)
+
+// GetGatewayStatuses returns a snapshot of the current Gateway listener statuses.
+func GetGatewayStatuses() []GatewayStatus {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	// Return a copy to avoid concurrent modification issues
+	statusCopy := make([]GatewayStatus, len(gatewayStatuses))
+	copy(statusCopy, gatewayStatuses)
+	return statusCopy
+}
+
+// CheckGateways checks the certificates referenced by Gateway API listeners.
+func CheckGateways(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Printf("Starting Gateway checks in cluster %s...", cluster.Name)
+
+	gatewayClient, err := k8s.ConnectToGatewayAPI(cluster.Config)
+	if err != nil {
+		return err
+	}
+
+	listCtx, span := startListSpan(ctx, cluster.Name, "gateways.gateway.networking.k8s.io")
+	gateways, err := gatewayClient.GatewayV1().Gateways("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Println("Gateway API is not installed in the cluster. Skipping Gateway checks.")
+			return nil
+		}
+		log.Errorf("Failed to list Gateways: %v", err)
+		return err
+	}
+
+	listCtx, span = startListSpan(ctx, cluster.Name, "referencegrants.gateway.networking.k8s.io")
+	grants, err := gatewayClient.GatewayV1beta1().ReferenceGrants("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Errorf("Failed to list ReferenceGrants: %v", err)
+		return err
+	}
+	var referenceGrants []gatewayv1beta1.ReferenceGrant
+	if grants != nil {
+		referenceGrants = grants.Items
+	}
+
+	statuses := []GatewayStatus{}
+	owners := newOwnerResolver(ctx, cluster)
+	for _, gateway := range gateways.Items {
+		owner := owners.resolve(gateway.Namespace, gateway.Labels, gateway.Annotations)
+		silence := annotationSilence("Gateway", gateway.Namespace, gateway.Name, gateway.Annotations)
+		for _, listener := range gateway.Spec.Listeners {
+			if listener.TLS == nil || len(listener.TLS.CertificateRefs) == 0 {
+				// Skip listeners that do not terminate TLS
+				continue
+			}
+
+			hostname := "*"
+			if listener.Hostname != nil && *listener.Hostname != "" {
+				hostname = string(*listener.Hostname)
+			}
+
+			probeStatus := "not probed"
+			var probeResults map[string]bool
+			if listener.Protocol == gatewayv1.HTTPSProtocolType {
+				probeStatus, probeResults = probeGatewayAddresses(ctx, gateway, listener)
+			}
+
+			for _, ref := range listener.TLS.CertificateRefs {
+				status := checkGatewayCertificateRef(ctx, cluster, referenceGrants, gateway, string(listener.Name), ref, hostname)
+				status.ProbeStatus = probeStatus
+				status.ProbeResults = probeResults
+				status.Owner = owner
+				status.AnnotationSilence = silence
+				log.Printf("Gateway: %s/%s, Listener: %s, Secret: %s, Status: %s, Probe: %s",
+					gateway.Namespace, gateway.Name, status.Listener, status.SecretRef, status.Status, status.ProbeStatus)
+				statuses = append(statuses, status)
+			}
+		}
+	}
+
+	record(results, &results.gateways, cluster.Name, statuses)
+
+	log.Println("Gateway checks completed.")
+	return nil
+}
+
+// checkGatewayCertificateRef resolves a listener certificateRef and validates the referenced certificate.
+func checkGatewayCertificateRef(ctx context.Context, cluster k8s.Cluster, grants []gatewayv1beta1.ReferenceGrant, gateway gatewayv1.Gateway, listener string, ref gatewayv1.SecretObjectReference, hostname string) GatewayStatus {
+	secretNamespace := gateway.Namespace
+	if ref.Namespace != nil && *ref.Namespace != "" {
+		secretNamespace = string(*ref.Namespace)
+	}
+
+	p := policy.Resolve(policy.Object{Kind: "Gateway", Namespace: gateway.Namespace, Name: gateway.Name, Labels: gateway.Labels})
+	status := GatewayStatus{
+		Cluster:         cluster.Name,
+		Namespace:       gateway.Namespace,
+		Gateway:         gateway.Name,
+		Listener:        listener,
+		Hostname:        hostname,
+		SecretRef:       fmt.Sprintf("%s/%s", secretNamespace, ref.Name),
+		ExpirationDate:  "unknown",
+		HostnameCovered: "unknown",
+		Policy:          p.Name,
+		Muted:           p.Muted,
+	}
+
+	if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Secret") {
+		status.Status = "unsupported reference"
+		return status
+	}
+
+	if secretNamespace != gateway.Namespace && !referenceGranted(grants, gateway.Namespace, secretNamespace, string(ref.Name)) {
+		log.Warnf("Gateway %s/%s references Secret %s without a ReferenceGrant", gateway.Namespace, gateway.Name, status.SecretRef)
+		status.Status = "reference not permitted"
+		return status
+	}
+
+	secret, err := cluster.Clientset.CoreV1().Secrets(secretNamespace).Get(ctx, string(ref.Name), metav1.GetOptions{})
+	if err != nil {
+		log.Errorf("Failed to get Secret %s for Gateway %s/%s: %v", status.SecretRef, gateway.Namespace, gateway.Name, err)
+		status.Status = "missing secret"
+		return status
+	}
+
+	certPEM, ok := secret.Data["tls.crt"]
+	if !ok {
+		status.Status = "missing cert"
+		return status
+	}
+	certs, err := parseCertificates(ctx, certPEM)
+	if err != nil {
+		log.Errorf("Failed to parse certificate in Secret %s: %v", status.SecretRef, err)
+		status.Status = "error parsing cert"
+		return status
+	}
+
+	leaf := certs[0]
+	status.Subject = nameOf(leaf.Subject)
+	status.Issuer = nameOf(leaf.Issuer)
+	status.Chain = describeChain(certs)
+	status.ExpirationDate = leaf.NotAfter.Format("2006-01-02")
+	status.NotAfter = leaf.NotAfter
+	status.DaysUntil, status.Status = expiryStatus(leaf.NotAfter, p)
+	if hostname == "*" {
+		status.HostnameCovered = "n/a"
+	} else if coversHostname(leaf, hostname) {
+		status.HostnameCovered = "yes"
+	} else {
+		status.HostnameCovered = "no"
+		log.Warnf("Certificate in Secret %s does not cover listener hostname %s", status.SecretRef, hostname)
+	}
+	return status
+}
+
+// referenceGranted reports whether a ReferenceGrant in toNamespace allows Gateways in fromNamespace to use the named Secret.
+func referenceGranted(grants []gatewayv1beta1.ReferenceGrant, fromNamespace, toNamespace, secretName string) bool {
+	for _, grant := range grants {
+		if grant.Namespace != toNamespace {
+			continue
+		}
+
+		fromAllowed := false
+		for _, from := range grant.Spec.From {
+			if from.Group == gatewayv1.GroupName && from.Kind == "Gateway" && string(from.Namespace) == fromNamespace {
+				fromAllowed = true
+				break
+			}
+		}
+		if !fromAllowed {
+			continue
+		}
+
+		for _, to := range grant.Spec.To {
+			if to.Group == "" && to.Kind == "Secret" && (to.Name == nil || string(*to.Name) == secretName) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// probeGatewayAddresses runs the SSL probe against every address of the Gateway on the listener port.
+// It returns a summary for the status page together with the outcome for each address.
+func probeGatewayAddresses(ctx context.Context, gateway gatewayv1.Gateway, listener gatewayv1.Listener) (string, map[string]bool) {
+	if len(gateway.Status.Addresses) == 0 {
+		return "no address", nil
+	}
+
+	results := make([]string, 0, len(gateway.Status.Addresses))
+	succeeded := make(map[string]bool, len(gateway.Status.Addresses))
+	for _, address := range gateway.Status.Addresses {
+		hostPort := net.JoinHostPort(address.Value, strconv.Itoa(int(listener.Port)))
+		result := checkSSL(ctx, fmt.Sprintf("https://%s", hostPort))
+		succeeded[address.Value] = result == "Valid"
+		results = append(results, fmt.Sprintf("%s: %s", address.Value, result))
+	}
+	return strings.Join(results, ", "), succeeded
+}
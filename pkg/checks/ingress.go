@@ -2,21 +2,28 @@ package checks
 
 import (
 	"context"
-	"crypto/tls"
-	"fmt"
-	"net/http"
-	"time"
+	"net"
+	"net/url"
 
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
 // IngressStatus represents the status of a TLS secret.
 type IngressStatus struct {
-	Namespace      string
-	IngressName    string
-	InternalStatus string
-	ExternalStatus string
+	Cluster           string
+	Namespace         string
+	IngressName       string
+	InternalStatus    string
+	ExternalStatus    string
+	Probes            []ProbeStatus
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
 }
 
 var (
@@ -35,69 +42,121 @@ func GetIngressStatuses() []IngressStatus {
 }
 
 // CheckIngress performs SSL checks on Ingress resources with TLS configured.
-func CheckIngress(ctx context.Context, clientset *kubernetes.Clientset) error {
-	log.Println("Starting Ingress checks...")
+func CheckIngress(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Printf("Starting Ingress checks in cluster %s...", cluster.Name)
 
 	// List all Ingresses in the cluster
-	ingresses, err := clientset.NetworkingV1().Ingresses("").List(ctx, metav1.ListOptions{})
+	listCtx, span := startListSpan(ctx, cluster.Name, "ingresses")
+	ingresses, err := cluster.Clientset.NetworkingV1().Ingresses("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
 	if err != nil {
 		log.Errorf("Failed to list Ingress resources: %v", err)
 		return err
 	}
 
+	statuses := []IngressStatus{}
+	owners := newOwnerResolver(ctx, cluster)
 	for _, ingress := range ingresses.Items {
 		if len(ingress.Spec.TLS) == 0 {
 			// Skip Ingress without TLS configured
 			continue
 		}
+		p := policy.Resolve(policy.Object{Kind: "Ingress", Namespace: ingress.Namespace, Name: ingress.Name, Labels: ingress.Labels})
+		owner := owners.resolve(ingress.Namespace, ingress.Labels, ingress.Annotations)
+		silence := annotationSilence("Ingress", ingress.Namespace, ingress.Name, ingress.Annotations)
+
+		// External check using Hostname (DNS), shared by every load balancer entry
+		externalStatus := "Unknown"
+		var hostProbes []ProbeStatus
+		if len(ingress.Status.LoadBalancer.Ingress) > 0 {
+			for _, rule := range ingress.Spec.Rules {
+				if rule.Host != "" {
+					var probe ProbeStatus
+					externalStatus, probe = probeIngress(ctx, cluster.Name, ingress.Namespace, ingress.Name, rule.Host, p)
+					hostProbes = append(hostProbes, probe)
+				}
+			}
+		}
 
 		// Get IP addresses from the Ingress status
 		for _, ingressStatus := range ingress.Status.LoadBalancer.Ingress {
 			internalStatus := "Unknown"
-			externalStatus := "Unknown"
+			probes := append([]ProbeStatus(nil), hostProbes...)
 
 			// Internal check using IP
 			if ingressStatus.IP != "" {
-				internalStatus = checkSSL(fmt.Sprintf("https://%s", ingressStatus.IP))
-			}
-
-			// External check using Hostname (DNS)
-			for _, rule := range ingress.Spec.Rules {
-				if rule.Host != "" {
-					externalStatus = checkSSL(fmt.Sprintf("https://%s", rule.Host))
-				}
+				var probe ProbeStatus
+				internalStatus, probe = probeIngress(ctx, cluster.Name, ingress.Namespace, ingress.Name, ingressStatus.IP, p)
+				probes = append([]ProbeStatus{probe}, probes...)
 			}
 
 			log.Printf("Ingress: %s/%s, Internal SSL: %s, External SSL: %s",
 				ingress.Namespace, ingress.Name, internalStatus, externalStatus)
+			statuses = append(statuses, IngressStatus{
+				Cluster:           cluster.Name,
+				Namespace:         ingress.Namespace,
+				IngressName:       ingress.Name,
+				InternalStatus:    internalStatus,
+				ExternalStatus:    externalStatus,
+				Probes:            probes,
+				Owner:             owner,
+				Policy:            p.Name,
+				Muted:             p.Muted,
+				AnnotationSilence: silence,
+			})
 		}
 	}
+	record(results, &results.ingresses, cluster.Name, statuses)
 
 	log.Println("Ingress checks completed.")
 	return nil
 }
 
-// checkSSL validates the SSL connection for the given URL.
-func checkSSL(url string) string {
-	// Configure an HTTP client with a timeout and TLS config
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true, // Skip cert verification for this check
-			},
-		},
+// probeIngress probes the HTTPS endpoint of an Ingress at host, an IP address or a hostname, and
+// returns whether the TLS handshake succeeded as Valid or Failed together with the negotiated
+// parameters and weaknesses of the endpoint. The certificate is not required to be trusted, and
+// endpoints that do not answer with a valid HTTP response are still checked.
+func probeIngress(ctx context.Context, cluster, namespace, name, host string, p policy.Resolved) (string, ProbeStatus) {
+	probe := probeEndpoint(ctx, cluster, namespace, name, net.JoinHostPort(host, "443"), host, "https", p)
+	if probe.Status == "unreachable" {
+		log.Errorf("SSL check failed for https://%s", host)
+		return "Failed", probe
+	}
+	return "Valid", probe
+}
+
+// checkSSL validates the SSL connection for the given URL. Only the TLS handshake is required to
+// succeed, so endpoints that do not answer with a valid HTTP response are still checked.
+func checkSSL(ctx context.Context, rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		log.Errorf("SSL check failed for URL %s: invalid URL", rawURL)
+		return "Invalid"
 	}
+	port := parsed.Port()
+	if port == "" {
+		port = "443"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
 
-	resp, err := client.Get(url)
+	_, err = fetchPeerCertificates(ctx, net.JoinHostPort(parsed.Hostname(), port), parsed.Hostname(), "https")
 	if err != nil {
-		log.Errorf("SSL check failed for URL %s: %v", url, err)
+		log.Errorf("SSL check failed for URL %s: %v", rawURL, err)
 		return "Failed"
 	}
-	defer resp.Body.Close()
+	return "Valid"
+}
 
-	if resp.TLS != nil {
-		return "Valid"
+// TLSFindings returns the weaknesses found on the probed endpoints of the Ingress, each prefixed with
+// its address.
+func (s IngressStatus) TLSFindings() []string {
+	var findings []string
+	for _, probe := range s.Probes {
+		for _, finding := range probe.Findings {
+			findings = append(findings, probe.Address+": "+finding)
+		}
 	}
-	return "Invalid"
+	return findings
 }
@@ -0,0 +1,262 @@
+package checks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/revocation"
+)
+
+// objectCounts counts the objects examined by each check, by cluster and status.
+type objectCounts map[[3]string]int
+
+func (c objectCounts) add(cluster, check, status string) {
+	c[[3]string{cluster, check, status}]++
+}
+
+// updateMetrics rebuilds the per-object metric families from the published statuses, removing the
+// series of objects that no longer exist. Objects muted by their policy are only counted, so that the
+// alerts built on the per-object series ignore them. statusLock must be held.
+func updateMetrics() {
+	counts := objectCounts{}
+	updateSecretMetrics(counts)
+	updateCertManagerMetrics(counts)
+	updateIngressMetrics(counts)
+	updateWebhookMetrics(counts)
+	updateGatewayMetrics(counts)
+	updateRouteMetrics(counts)
+	updateProbeMetrics(counts)
+	updateSilenceMetrics()
+
+	series := make([]metrics.Series, 0, len(counts))
+	for labels, count := range counts {
+		series = append(series, metrics.Series{Labels: labels[:], Value: float64(count)})
+	}
+	metrics.CheckedObjects.Replace(series)
+}
+
+// updateSecretMetrics exports the expiry, status and revocation of TLS secrets.
+func updateSecretMetrics(counts objectCounts) {
+	var notAfter, states, revoked []metrics.Series
+	for _, s := range secretStatuses {
+		counts.add(s.Cluster, "secrets", s.Status)
+		if s.Muted {
+			continue
+		}
+		notAfter = appendNotAfter(notAfter, s.NotAfter, s.Cluster, s.Namespace, s.SecretName)
+		states = append(states, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.SecretName, s.Status}, Value: 1})
+		revoked = appendRevocation(revoked, s.Revocation, s.Cluster, "secret", s.Namespace, s.SecretName)
+	}
+	metrics.TLSSecretNotAfter.Replace(notAfter)
+	metrics.TLSSecretStatus.Replace(states)
+	metrics.CertificateRevoked.Replace(append(revoked, probeRevocations()...))
+}
+
+// updateCertManagerMetrics exports the expiry and readiness of cert-manager Certificates.
+func updateCertManagerMetrics(counts objectCounts) {
+	var notAfter, states []metrics.Series
+	for _, s := range certManagerStatuses {
+		counts.add(s.Cluster, "cert-manager", s.Status)
+		if s.Muted {
+			continue
+		}
+		notAfter = appendNotAfter(notAfter, s.NotAfter, s.Cluster, s.Namespace, s.Certificate)
+		states = append(states, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.Certificate, s.Status, s.RenewalFailure}, Value: 1})
+	}
+	metrics.CertManagerCertificateNotAfter.Replace(notAfter)
+	metrics.CertManagerCertificateStatus.Replace(states)
+}
+
+// updateIngressMetrics exports the outcome of the Ingress SSL probes. An Ingress with several load
+// balancer entries only succeeds when every entry does.
+func updateIngressMetrics(counts objectCounts) {
+	success := map[[4]string]float64{}
+	probed := func(s IngressStatus, endpoint, result string) {
+		if result == "Unknown" {
+			return
+		}
+		key := [4]string{s.Cluster, s.Namespace, s.IngressName, endpoint}
+		value := 0.0
+		if result == "Valid" {
+			value = 1
+		}
+		if current, ok := success[key]; !ok || value < current {
+			success[key] = value
+		}
+	}
+	for _, s := range ingressStatus {
+		counts.add(s.Cluster, "ingress", s.State())
+		if s.Muted {
+			continue
+		}
+		probed(s, "internal", s.InternalStatus)
+		probed(s, "external", s.ExternalStatus)
+	}
+
+	series := make([]metrics.Series, 0, len(success))
+	for labels, value := range success {
+		series = append(series, metrics.Series{Labels: labels[:], Value: value})
+	}
+	metrics.IngressProbeSuccess.Replace(series)
+}
+
+// State summarizes the internal and external probes of an Ingress as failed, invalid, unknown or valid.
+func (s IngressStatus) State() string {
+	for _, state := range []string{"Failed", "Invalid"} {
+		if s.InternalStatus == state || s.ExternalStatus == state {
+			return strings.ToLower(state)
+		}
+	}
+	if s.InternalStatus == "Unknown" && s.ExternalStatus == "Unknown" {
+		return "unknown"
+	}
+	return "valid"
+}
+
+// updateWebhookMetrics exports the expiry and status of the CAs embedded in caBundles.
+func updateWebhookMetrics(counts objectCounts) {
+	var notAfter, states []metrics.Series
+	for _, s := range webhookStatuses {
+		counts.add(s.Cluster, "webhooks", s.Status)
+		if s.Muted {
+			continue
+		}
+		notAfter = appendNotAfter(notAfter, s.NotAfter, s.Cluster, s.Kind, s.Name, s.Webhook, s.CASubject, s.CAFingerprint)
+		states = append(states, metrics.Series{Labels: []string{s.Cluster, s.Kind, s.Name, s.Webhook, s.CASubject, s.CAFingerprint, s.Status}, Value: 1})
+	}
+	metrics.WebhookCABundleNotAfter.Replace(notAfter)
+	metrics.WebhookCABundleStatus.Replace(states)
+}
+
+// updateGatewayMetrics exports the expiry and status of Gateway listener certificates and the
+// outcome of the listener address probes.
+func updateGatewayMetrics(counts objectCounts) {
+	var notAfter, states, probes []metrics.Series
+	for _, s := range gatewayStatuses {
+		counts.add(s.Cluster, "gateways", s.Status)
+		if s.Muted {
+			continue
+		}
+		notAfter = appendNotAfter(notAfter, s.NotAfter, s.Cluster, s.Namespace, s.Gateway, s.Listener, s.SecretRef)
+		states = append(states, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.Gateway, s.Listener, s.SecretRef, s.Status}, Value: 1})
+		for address, succeeded := range s.ProbeResults {
+			probes = append(probes, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.Gateway, s.Listener, address}, Value: boolValue(succeeded)})
+		}
+	}
+	metrics.GatewayCertificateNotAfter.Replace(notAfter)
+	metrics.GatewayCertificateStatus.Replace(states)
+	metrics.GatewayProbeSuccess.Replace(probes)
+}
+
+// updateRouteMetrics exports the expiry and status of certificates embedded in OpenShift Routes.
+func updateRouteMetrics(counts objectCounts) {
+	var notAfter, states []metrics.Series
+	for _, s := range routeStatuses {
+		counts.add(s.Cluster, "routes", s.Status)
+		if s.Muted {
+			continue
+		}
+		notAfter = appendNotAfter(notAfter, s.NotAfter, s.Cluster, s.Namespace, s.Route, s.Field)
+		states = append(states, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.Route, s.Field, s.Status}, Value: 1})
+	}
+	metrics.RouteCertificateNotAfter.Replace(notAfter)
+	metrics.RouteCertificateStatus.Replace(states)
+}
+
+// updateProbeMetrics exports the results of the Service and external endpoint probes, and the TLS
+// parameters of the Ingress probes.
+func updateProbeMetrics(counts objectCounts) {
+	var serviceNotAfter, serviceStates, externalNotAfter, externalSuccess, externalStates, info, findings []metrics.Series
+	for _, s := range serviceStatuses {
+		counts.add(s.Cluster, "services", s.Status)
+		if s.Muted {
+			continue
+		}
+		serviceNotAfter = appendNotAfter(serviceNotAfter, s.NotAfter, s.Cluster, s.Namespace, s.Name, s.Address)
+		serviceStates = append(serviceStates, metrics.Series{Labels: []string{s.Cluster, s.Namespace, s.Name, s.Address, s.Status}, Value: 1})
+		info, findings = appendTLSParameters(info, findings, "service", s)
+	}
+	for _, s := range externalStatuses {
+		counts.add(s.Cluster, "external", s.Status)
+		if s.Muted {
+			continue
+		}
+		externalNotAfter = appendNotAfter(externalNotAfter, s.NotAfter, s.Cluster, s.Address, s.SNI)
+		externalSuccess = append(externalSuccess, metrics.Series{Labels: []string{s.Cluster, s.Address, s.SNI}, Value: boolValue(s.Status != "unreachable")})
+		externalStates = append(externalStates, metrics.Series{Labels: []string{s.Cluster, s.Address, s.SNI, s.Status}, Value: 1})
+		info, findings = appendTLSParameters(info, findings, "external", s)
+	}
+	for _, s := range ingressStatus {
+		if s.Muted {
+			continue
+		}
+		for _, probe := range s.Probes {
+			info, findings = appendTLSParameters(info, findings, "ingress", probe)
+		}
+	}
+	metrics.ServiceCertificateNotAfter.Replace(serviceNotAfter)
+	metrics.ServiceProbeStatus.Replace(serviceStates)
+	metrics.ExternalCertificateNotAfter.Replace(externalNotAfter)
+	metrics.ExternalProbeSuccess.Replace(externalSuccess)
+	metrics.ExternalProbeStatus.Replace(externalStates)
+	metrics.TLSProbeInfo.Replace(info)
+	metrics.TLSProbeFindings.Replace(findings)
+}
+
+// probeRevocations returns the revocation series of the Service and external endpoint probes.
+func probeRevocations() []metrics.Series {
+	var revoked []metrics.Series
+	for _, s := range serviceStatuses {
+		if s.Muted {
+			continue
+		}
+		revoked = appendRevocation(revoked, s.Revocation, s.Cluster, "service", s.Namespace, s.Name)
+	}
+	for _, s := range externalStatuses {
+		if s.Muted {
+			continue
+		}
+		revoked = appendRevocation(revoked, s.Revocation, s.Cluster, "external", s.Namespace, s.Name)
+	}
+	return revoked
+}
+
+// appendTLSParameters appends the negotiated parameters and findings of a successful probe.
+func appendTLSParameters(info, findings []metrics.Series, source string, s ProbeStatus) ([]metrics.Series, []metrics.Series) {
+	if s.TLSVersion == "" {
+		return info, findings
+	}
+	info = append(info, metrics.Series{Labels: []string{s.Cluster, source, s.Namespace, s.Name, s.Address, s.TLSVersion, s.CipherSuite, s.ALPN}, Value: 1})
+	for _, finding := range s.Findings {
+		findings = append(findings, metrics.Series{Labels: []string{s.Cluster, source, s.Namespace, s.Name, s.Address, finding}, Value: 1})
+	}
+	return info, findings
+}
+
+// appendNotAfter appends an expiry timestamp; certificates that could not be read have none.
+func appendNotAfter(series []metrics.Series, notAfter time.Time, labels ...string) []metrics.Series {
+	if notAfter.IsZero() {
+		return series
+	}
+	return append(series, metrics.Series{Labels: labels, Value: float64(notAfter.Unix())})
+}
+
+// appendRevocation appends definitive revocation answers; unknown results have no series.
+func appendRevocation(series []metrics.Series, status string, labels ...string) []metrics.Series {
+	switch status {
+	case revocation.StatusGood:
+		return append(series, metrics.Series{Labels: labels, Value: 0})
+	case revocation.StatusRevoked:
+		return append(series, metrics.Series{Labels: labels, Value: 1})
+	}
+	return series
+}
+
+// boolValue converts a boolean to a gauge value.
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package checks
+
+import (
+	"context"
+	"sync"
+
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OwnerAnnotation names the team that owns an object or, on a namespace, every object in it.
+const OwnerAnnotation = "kubecertwatch.io/owner"
+
+// ownerKeys are the annotations and labels naming the owner of an object, in order of precedence.
+var ownerKeys = []string{OwnerAnnotation, "team", "app.kubernetes.io/part-of"}
+
+// ownerResolver resolves the owners of the objects of a cluster. Namespaces are listed on first use,
+// once per check.
+type ownerResolver struct {
+	ctx        context.Context
+	cluster    k8s.Cluster
+	once       sync.Once
+	namespaces map[string]string
+}
+
+// newOwnerResolver returns an owner resolver for the objects of cluster.
+func newOwnerResolver(ctx context.Context, cluster k8s.Cluster) *ownerResolver {
+	return &ownerResolver{ctx: ctx, cluster: cluster}
+}
+
+// resolve returns the owner named by the annotations or labels of an object, falling back to those of
+// its namespace. It returns an empty string when no owner is set.
+func (r *ownerResolver) resolve(namespace string, labels, annotations map[string]string) string {
+	if owner := ownerOf(labels, annotations); owner != "" {
+		return owner
+	}
+	if namespace == "" {
+		return ""
+	}
+	r.once.Do(r.loadNamespaces)
+	return r.namespaces[namespace]
+}
+
+// loadNamespaces records the owners of the namespaces of the cluster.
+func (r *ownerResolver) loadNamespaces() {
+	r.namespaces = map[string]string{}
+	if r.cluster.Clientset == nil {
+		return
+	}
+	namespaces, err := r.cluster.Clientset.CoreV1().Namespaces().List(r.ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Warnf("Failed to list namespaces in cluster %s, owners are only read from the objects: %v", r.cluster.Name, err)
+		return
+	}
+	for _, namespace := range namespaces.Items {
+		if owner := ownerOf(namespace.Labels, namespace.Annotations); owner != "" {
+			r.namespaces[namespace.Name] = owner
+		}
+	}
+}
+
+// ownerOf returns the first owner key set as an annotation or label, preferring annotations.
+func ownerOf(labels, annotations map[string]string) string {
+	for _, key := range ownerKeys {
+		if owner := annotations[key]; owner != "" {
+			return owner
+		}
+		if owner := labels[key]; owner != "" {
+			return owner
+		}
+	}
+	return ""
+}
@@ -0,0 +1,227 @@
+package checks
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/revocation"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// probeTimeout bounds the dial and TLS handshake of a single probe.
+const probeTimeout = 10 * time.Second
+
+// legacyTLSVersions are the protocol versions whose acceptance is reported as a weakness.
+var legacyTLSVersions = []uint16{tls.VersionTLS10, tls.VersionTLS11}
+
+// ProbeStatus represents the certificate chain served by a probed TLS endpoint.
+type ProbeStatus struct {
+	Cluster           string
+	Namespace         string
+	Name              string
+	Address           string
+	SNI               string
+	Protocol          string
+	Issuer            string
+	Chain             string
+	ExpirationDate    string
+	NotAfter          time.Time
+	DaysUntil         int
+	Status            string
+	TLSVersion        string
+	CipherSuite       string
+	ALPN              string
+	Findings          []string
+	Revocation        string
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
+}
+
+// probeEndpoint performs a TLS handshake with addr, after any STARTTLS upgrade required by protocol,
+// and evaluates the expiry of the served chain under the endpoint's policy p.
+func probeEndpoint(ctx context.Context, cluster, namespace, name, addr, serverName, protocol string, p policy.Resolved) ProbeStatus {
+	status := ProbeStatus{
+		Cluster:        cluster,
+		Namespace:      namespace,
+		Name:           name,
+		Address:        addr,
+		SNI:            serverName,
+		Protocol:       protocol,
+		ExpirationDate: "unknown",
+		Revocation:     revocation.StatusNotChecked,
+		Policy:         p.Name,
+		Muted:          p.Muted,
+	}
+
+	state, err := handshake(ctx, addr, serverName, protocol, acceptAllVersions)
+	if err != nil {
+		log.Warnf("TLS probe of %s failed: %v", addr, err)
+		status.Status = "unreachable"
+		return status
+	}
+	chain := state.PeerCertificates
+
+	status.TLSVersion = tls.VersionName(state.Version)
+	status.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	status.ALPN = state.NegotiatedProtocol
+	status.Findings = tlsFindings(state)
+	if config.Current().ProbeLegacyTLS {
+		status.Findings = append(status.Findings, legacyVersionFindings(ctx, addr, serverName, protocol)...)
+	}
+	for _, finding := range status.Findings {
+		log.Warnf("TLS endpoint %s: %s", addr, finding)
+	}
+
+	// The chain is only as valid as its soonest-expiring certificate
+	expiring := earliestExpiring(chain)
+	status.Issuer = nameOf(chain[0].Issuer)
+	status.Chain = describeChain(chain)
+	status.ExpirationDate = expiring.NotAfter.Format("2006-01-02")
+	status.NotAfter = expiring.NotAfter
+	status.DaysUntil, status.Status = expiryStatus(expiring.NotAfter, p)
+
+	if config.Current().RevocationCheck {
+		var issuer *x509.Certificate
+		if len(chain) > 1 {
+			issuer = findIssuer(chain[0], chain[1:])
+		}
+		status.Revocation = revocationChecker.CheckStaple(state.OCSPResponse, chain[0], issuer)
+		if status.Revocation == revocation.StatusRevoked {
+			log.Warnf("Certificate served by %s has been revoked", addr)
+			status.Status = "revoked"
+		}
+	}
+	return status
+}
+
+// fetchPeerCertificates connects to addr, performs the upgrade required by protocol and returns the
+// certificate chain presented by the server during the TLS handshake.
+func fetchPeerCertificates(ctx context.Context, addr, serverName, protocol string) ([]*x509.Certificate, error) {
+	state, err := handshake(ctx, addr, serverName, protocol, nil)
+	if err != nil {
+		return nil, err
+	}
+	return state.PeerCertificates, nil
+}
+
+// handshake connects to addr, performs the upgrade required by protocol and completes a TLS handshake.
+// configure may adjust the client configuration, for example to pin the protocol version.
+func handshake(ctx context.Context, addr, serverName, protocol string, configure func(*tls.Config)) (state tls.ConnectionState, err error) {
+	ctx, span := telemetry.StartSpan(ctx, "TLS handshake",
+		attribute.String("address", addr),
+		attribute.String("server_name", serverName),
+		attribute.String("protocol", protocol),
+	)
+	defer func() {
+		if err == nil {
+			span.SetAttributes(attribute.String("tls_version", tls.VersionName(state.Version)))
+		}
+		telemetry.EndSpan(span, err)
+	}()
+
+	dialer := &net.Dialer{Timeout: probeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(probeTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return tls.ConnectionState{}, err
+	}
+
+	if err := startTLS(conn, protocol, serverName); err != nil {
+		return tls.ConnectionState{}, fmt.Errorf("%s upgrade failed: %w", protocol, err)
+	}
+
+	// Probes inspect whatever chain is served, including those signed by private CAs. Only webhook
+	// probes check trust, against their caBundle in verifyServingChain; Service, external and Ingress
+	// probes report expiry and TLS parameters but never verify the chain.
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	}
+	if protocol == "https" {
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+	if configure != nil {
+		configure(tlsConfig)
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return tls.ConnectionState{}, err
+	}
+
+	state = tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return tls.ConnectionState{}, errors.New("server presented no certificates")
+	}
+	return state, nil
+}
+
+// acceptAllVersions lets a handshake negotiate legacy protocol versions and cipher suites, so that
+// endpoints which only offer them can still be inspected and reported.
+func acceptAllVersions(tlsConfig *tls.Config) {
+	tlsConfig.MinVersion = tls.VersionTLS10
+	for _, suite := range tls.CipherSuites() {
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, suite.ID)
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, suite.ID)
+	}
+}
+
+// tlsFindings reports the weaknesses of a negotiated connection.
+func tlsFindings(state tls.ConnectionState) []string {
+	var findings []string
+	if state.Version < tls.VersionTLS12 {
+		findings = append(findings, "negotiated "+tls.VersionName(state.Version))
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		if suite.ID == state.CipherSuite {
+			findings = append(findings, "weak cipher suite "+suite.Name)
+		}
+	}
+	return findings
+}
+
+// legacyVersionFindings reports which legacy protocol versions addr still accepts.
+func legacyVersionFindings(ctx context.Context, addr, serverName, protocol string) []string {
+	var findings []string
+	for _, version := range legacyTLSVersions {
+		_, err := handshake(ctx, addr, serverName, protocol, func(tlsConfig *tls.Config) {
+			acceptAllVersions(tlsConfig)
+			tlsConfig.MinVersion = version
+			tlsConfig.MaxVersion = version
+		})
+		if err == nil {
+			findings = append(findings, "accepts "+tls.VersionName(version))
+		}
+	}
+	return findings
+}
+
+// hostOf returns the host part of a host:port address.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,91 @@
+package checks
+
+import "sync"
+
+// clusterResults holds the statuses produced for each cluster during a run.
+type clusterResults[T clusterScoped] map[string][]T
+
+// Results collects the statuses produced by a run so that they are published together once the run
+// completes. Readers never observe a partially completed run.
+type Results struct {
+	mu          sync.Mutex
+	secrets     clusterResults[SecretStatus]
+	certManager clusterResults[CertManagerStatus]
+	ingresses   clusterResults[IngressStatus]
+	webhooks    clusterResults[WebhookStatus]
+	gateways    clusterResults[GatewayStatus]
+	routes      clusterResults[RouteStatus]
+	services    clusterResults[ProbeStatus]
+	external    clusterResults[ProbeStatus]
+}
+
+// NewResults returns an empty result set for a run.
+func NewResults() *Results {
+	return &Results{}
+}
+
+// record stores the statuses of cluster in target, replacing any earlier attempt of the same check.
+func record[T clusterScoped](r *Results, target *clusterResults[T], cluster string, statuses []T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if *target == nil {
+		*target = clusterResults[T]{}
+	}
+	(*target)[cluster] = statuses
+}
+
+// publish merges the statuses of every cluster in results into current.
+func publish[T clusterScoped](current []T, results clusterResults[T]) []T {
+	for cluster, statuses := range results {
+		current = mergeClusterStatuses(current, cluster, statuses)
+	}
+	return current
+}
+
+// Publish swaps the statuses collected by the run into the status pages in a single step. When retain
+// is not nil, the statuses of clusters that are not listed are dropped at the same time.
+func (r *Results) Publish(retain []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	secretStatuses = publish(secretStatuses, r.secrets)
+	certManagerStatuses = publish(certManagerStatuses, r.certManager)
+	ingressStatus = publish(ingressStatus, r.ingresses)
+	webhookStatuses = publish(webhookStatuses, r.webhooks)
+	gatewayStatuses = publish(gatewayStatuses, r.gateways)
+	routeStatuses = publish(routeStatuses, r.routes)
+	serviceStatuses = publish(serviceStatuses, r.services)
+	externalStatuses = publish(externalStatuses, r.external)
+
+	if retain != nil {
+		retainClusters(clusterSet(retain))
+	}
+	updateMetrics()
+}
+
+// Count returns the number of objects examined by the named check in cluster during the run.
+func (r *Results) Count(check, cluster string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch check {
+	case "secrets":
+		return len(r.secrets[cluster])
+	case "cert-manager":
+		return len(r.certManager[cluster])
+	case "ingress":
+		return len(r.ingresses[cluster])
+	case "webhooks":
+		return len(r.webhooks[cluster])
+	case "gateways":
+		return len(r.gateways[cluster])
+	case "routes":
+		return len(r.routes[cluster])
+	case "services":
+		return len(r.services[cluster])
+	case "external":
+		return len(r.external[cluster])
+	}
+	return 0
+}
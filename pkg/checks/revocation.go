@@ -0,0 +1,39 @@
+package checks
+
+import (
+	"context"
+	"crypto/x509"
+	"net/http"
+
+	"github.com/supporttools/KubeCertWatch/pkg/revocation"
+	v1 "k8s.io/api/core/v1"
+)
+
+// revocationChecker is shared by all checks so that OCSP and CRL answers are cached across runs.
+var revocationChecker = revocation.NewChecker(&http.Client{Timeout: probeTimeout})
+
+// checkSecretRevocation queries OCSP and CRL endpoints for the leaf certificate of a TLS secret.
+// The issuer is taken from the chain in tls.crt or from ca.crt when present.
+func checkSecretRevocation(ctx context.Context, cluster string, secret v1.Secret) string {
+	certs, err := parseCertificates(ctx, secret.Data["tls.crt"])
+	if err != nil {
+		return revocation.StatusUnknown
+	}
+
+	candidates := certs[1:]
+	if caCerts, err := parseCertificates(ctx, secret.Data["ca.crt"]); err == nil {
+		candidates = append(candidates, caCerts...)
+	}
+
+	return revocationChecker.Check(ctx, certs[0], findIssuer(certs[0], candidates))
+}
+
+// findIssuer returns the candidate that signed cert, or nil when none did.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
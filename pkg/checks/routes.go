@@ -0,0 +1,161 @@
+package checks
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// routeGVR is the GroupVersionResource of OpenShift Routes.
+var routeGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+
+// routeTLSFields are the inline PEM fields of a Route's spec.tls that are checked.
+var routeTLSFields = []string{"certificate", "caCertificate", "destinationCACertificate"}
+
+// RouteStatus represents the status of a certificate embedded inline in an OpenShift Route.
+type RouteStatus struct {
+	Cluster           string
+	Namespace         string
+	Route             string
+	Host              string
+	Field             string
+	Subject           string
+	Issuer            string
+	Chain             string
+	ExpirationDate    string
+	NotAfter          time.Time
+	DaysUntil         int
+	HostnameCovered   string
+	Status            string
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
+}
+
+var (
+	routeStatuses []RouteStatus
+)
+
+// GetRouteStatuses returns a snapshot of the current Route statuses.
+func GetRouteStatuses() []RouteStatus {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	// Return a copy to avoid concurrent modification issues
+	statusCopy := make([]RouteStatus, len(routeStatuses))
+	copy(statusCopy, routeStatuses)
+	return statusCopy
+}
+
+// CheckRoutes parses the certificates embedded in OpenShift Routes and checks their expiration and hostname coverage.
+func CheckRoutes(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Printf("Starting Route checks in cluster %s...", cluster.Name)
+
+	dynamicClient, err := cluster.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	listCtx, span := startListSpan(ctx, cluster.Name, "routes.route.openshift.io")
+	routeList, err := dynamicClient.Resource(routeGVR).Namespace("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			log.Println("OpenShift Route API is not installed in the cluster. Skipping Route checks.")
+			return nil
+		}
+		log.Errorf("Failed to list Routes: %v", err)
+		return err
+	}
+
+	statuses := []RouteStatus{}
+	owners := newOwnerResolver(ctx, cluster)
+	for _, route := range routeList.Items {
+		host, _, _ := unstructured.NestedString(route.Object, "spec", "host")
+		p := policy.Resolve(policy.Object{Kind: "Route", Namespace: route.GetNamespace(), Name: route.GetName(), Labels: route.GetLabels()})
+		owner := owners.resolve(route.GetNamespace(), route.GetLabels(), route.GetAnnotations())
+		silence := annotationSilence("Route", route.GetNamespace(), route.GetName(), route.GetAnnotations())
+
+		for _, field := range routeTLSFields {
+			pemData, found, _ := unstructured.NestedString(route.Object, "spec", "tls", field)
+			if !found || pemData == "" {
+				continue
+			}
+			status := checkRouteCertificate(ctx, cluster.Name, route.GetNamespace(), route.GetName(), host, field, []byte(pemData), p)
+			status.Owner = owner
+			status.AnnotationSilence = silence
+			log.Debugf("Route: %s/%s, Field: %s, Status: %s", status.Namespace, status.Route, status.Field, status.Status)
+			statuses = append(statuses, status)
+		}
+	}
+
+	record(results, &results.routes, cluster.Name, statuses)
+
+	log.Println("Route checks completed.")
+	return nil
+}
+
+// checkRouteCertificate validates one inline PEM field of a Route under the Route's policy p.
+func checkRouteCertificate(ctx context.Context, cluster, namespace, name, host, field string, pemData []byte, p policy.Resolved) RouteStatus {
+	status := RouteStatus{
+		Cluster:         cluster,
+		Namespace:       namespace,
+		Route:           name,
+		Host:            host,
+		Field:           field,
+		ExpirationDate:  "unknown",
+		HostnameCovered: "n/a",
+		Policy:          p.Name,
+		Muted:           p.Muted,
+	}
+
+	certs, err := parseCertificates(ctx, pemData)
+	if err != nil {
+		log.Errorf("Failed to parse %s of Route %s/%s: %v", field, namespace, name, err)
+		status.Status = "error parsing cert"
+		return status
+	}
+
+	// The serving certificate is the leaf; for CA fields the soonest-expiring CA decides the status
+	cert := certs[0]
+	if field != "certificate" {
+		cert = earliestExpiring(certs)
+	}
+
+	status.Subject = nameOf(cert.Subject)
+	status.Issuer = nameOf(cert.Issuer)
+	status.Chain = describeChain(certs)
+	status.ExpirationDate = cert.NotAfter.Format("2006-01-02")
+	status.NotAfter = cert.NotAfter
+	status.DaysUntil, status.Status = expiryStatus(cert.NotAfter, p)
+	if field == "certificate" && host != "" {
+		if coversHostname(cert, host) {
+			status.HostnameCovered = "yes"
+		} else {
+			status.HostnameCovered = "no"
+			log.Warnf("Certificate of Route %s/%s does not cover host %s", namespace, name, host)
+		}
+	}
+	return status
+}
+
+// earliestExpiring returns the certificate with the soonest NotAfter.
+func earliestExpiring(certs []*x509.Certificate) *x509.Certificate {
+	earliest := certs[0]
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
+	return earliest
+}
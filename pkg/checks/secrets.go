@@ -2,28 +2,39 @@ package checks
 
 import (
 	"context"
-	"crypto/x509"
-	"encoding/pem"
-	"errors"
 	"sync"
 	"time"
 
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
 	"github.com/supporttools/KubeCertWatch/pkg/logging"
-	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/revocation"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
 var log = logging.SetupLogging()
 
 // SecretStatus represents the status of a TLS secret.
 type SecretStatus struct {
-	Namespace      string
-	SecretName     string
-	ExpirationDate string
-	DaysUntil      int
-	Status         string
+	Cluster           string
+	Namespace         string
+	SecretName        string
+	ExpirationDate    string
+	NotAfter          time.Time
+	DaysUntil         int
+	Status            string
+	Subject           string
+	Issuer            string
+	Chain             string
+	Revocation        string
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
 }
 
 var (
@@ -43,50 +54,57 @@ func GetSecretStatuses() []SecretStatus {
 }
 
 // CheckTLSSecrets scans all secrets in the cluster for TLS secrets and checks their expiration dates.
-func CheckTLSSecrets(ctx context.Context, clientset *kubernetes.Clientset) error {
-	log.Debug("Acquiring lock for secretStatuses")
-	statusLock.Lock()
-	defer statusLock.Unlock()
-
-	log.Debug("Clearing previous secret statuses")
-	secretStatuses = []SecretStatus{} // Clear previous statuses
-
-	log.Debug("Listing all secrets in the cluster")
-	secrets, err := clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{})
+func CheckTLSSecrets(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Debugf("Listing all secrets in cluster %s", cluster.Name)
+	listCtx, span := startListSpan(ctx, cluster.Name, "secrets")
+	secrets, err := cluster.Clientset.CoreV1().Secrets("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
 	if err != nil {
 		log.Errorf("Failed to list secrets: %v", err)
 		return err
 	}
 	log.Debugf("Found %d secrets in the cluster", len(secrets.Items))
 
+	statuses := []SecretStatus{}
+	owners := newOwnerResolver(ctx, cluster)
+
 	for _, secret := range secrets.Items {
 		log.Debugf("Processing secret: %s/%s", secret.Namespace, secret.Name)
 		if secret.Type == v1.SecretTypeTLS {
 			log.Debugf("Secret %s/%s is of type TLS", secret.Namespace, secret.Name)
+			p := policy.Resolve(policy.Object{Kind: "Secret", Namespace: secret.Namespace, Name: secret.Name, Labels: secret.Labels})
 
 			expirationDate := "unknown"
+			var notAfter time.Time
 			daysUntil := 0
 			status := "valid"
+			var subject, issuer, chain string
+			revocationStatus := revocation.StatusNotChecked
 
 			certPEM, ok := secret.Data["tls.crt"]
 			if ok {
 				log.Debugf("Found tls.crt in secret %s/%s. Parsing certificate...", secret.Namespace, secret.Name)
-				expiration, err := getCertificateExpiration(certPEM)
+				certs, err := parseCertificates(ctx, certPEM)
 				if err != nil {
 					log.Errorf("Failed to parse certificate in secret %s/%s: %v", secret.Namespace, secret.Name, err)
 					status = "error parsing cert"
 				} else {
+					expiration := certs[0].NotAfter
+					subject, issuer, chain = nameOf(certs[0].Subject), nameOf(certs[0].Issuer), describeChain(certs)
 					expirationDate = expiration.Format("2006-01-02")
-					daysUntil = int(time.Until(expiration).Hours() / 24)
+					notAfter = expiration
+					daysUntil, status = expiryStatus(expiration, p)
 					log.Debugf("Certificate in secret %s/%s expires on %s (in %d days)", secret.Namespace, secret.Name, expirationDate, daysUntil)
+					if status != "valid" {
+						log.Warnf("Certificate in secret %s/%s is %s (%d days left)", secret.Namespace, secret.Name, status, daysUntil)
+					}
 
-					if time.Now().After(expiration) {
-						status = "expired"
-						daysUntil = int(time.Since(expiration).Hours()/24) * -1 // Negative days since expiration
-						log.Warnf("Certificate in secret %s/%s is expired by %d days", secret.Namespace, secret.Name, -daysUntil)
-					} else if daysUntil < 7 {
-						status = "expiring soon"
-						log.Warnf("Certificate in secret %s/%s is expiring soon", secret.Namespace, secret.Name)
+					if config.Current().RevocationCheck {
+						revocationStatus = checkSecretRevocation(ctx, cluster.Name, secret)
+						if revocationStatus == revocation.StatusRevoked {
+							status = "revoked"
+							log.Warnf("Certificate in secret %s/%s has been revoked", secret.Namespace, secret.Name)
+						}
 					}
 				}
 			} else {
@@ -94,18 +112,23 @@ func CheckTLSSecrets(ctx context.Context, clientset *kubernetes.Clientset) error
 				status = "missing cert"
 			}
 
-			// Update certificate expiry metrics
-			if status == "valid" || status == "expiring soon" {
-				metrics.CertificateExpiryDays.WithLabelValues(secret.Namespace, secret.Name).Set(float64(daysUntil))
-			}
-
 			// Add to status list
-			secretStatuses = append(secretStatuses, SecretStatus{
-				Namespace:      secret.Namespace,
-				SecretName:     secret.Name,
-				ExpirationDate: expirationDate,
-				DaysUntil:      daysUntil,
-				Status:         status,
+			statuses = append(statuses, SecretStatus{
+				Cluster:           cluster.Name,
+				Namespace:         secret.Namespace,
+				SecretName:        secret.Name,
+				ExpirationDate:    expirationDate,
+				NotAfter:          notAfter,
+				DaysUntil:         daysUntil,
+				Status:            status,
+				Subject:           subject,
+				Issuer:            issuer,
+				Chain:             chain,
+				Revocation:        revocationStatus,
+				Owner:             owners.resolve(secret.Namespace, secret.Labels, secret.Annotations),
+				Policy:            p.Name,
+				Muted:             p.Muted,
+				AnnotationSilence: annotationSilence("Secret", secret.Namespace, secret.Name, secret.Annotations),
 			})
 			log.Debugf("Added status for secret %s/%s: %v", secret.Namespace, secret.Name, status)
 		} else {
@@ -113,21 +136,8 @@ func CheckTLSSecrets(ctx context.Context, clientset *kubernetes.Clientset) error
 		}
 	}
 
+	record(results, &results.secrets, cluster.Name, statuses)
+
 	log.Debug("Completed processing all secrets")
 	return nil
 }
-
-// getCertificateExpiration parses the PEM-encoded certificate and returns the expiration date.
-func getCertificateExpiration(certPEM []byte) (time.Time, error) {
-	block, _ := pem.Decode(certPEM)
-	if block == nil || block.Type != "CERTIFICATE" {
-		return time.Time{}, errors.New("failed to decode PEM block containing certificate")
-	}
-
-	cert, err := x509.ParseCertificate(block.Bytes)
-	if err != nil {
-		return time.Time{}, err
-	}
-
-	return cert.NotAfter, nil
-}
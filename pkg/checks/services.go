@@ -0,0 +1,154 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ProbePortAnnotation opts a Service into TLS probing on the given port number or port name.
+	ProbePortAnnotation = "kubecertwatch.io/probe-port"
+	// ProbeSNIAnnotation sets the server name sent during the TLS handshake of a Service probe.
+	ProbeSNIAnnotation = "kubecertwatch.io/probe-sni"
+	// ProbeProtocolAnnotation selects the STARTTLS upgrade performed before the handshake of a Service probe.
+	ProbeProtocolAnnotation = "kubecertwatch.io/probe-protocol"
+)
+
+var (
+	serviceStatuses []ProbeStatus
+)
+
+// GetServiceStatuses returns a snapshot of the current Service probe statuses.
+func GetServiceStatuses() []ProbeStatus {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	// Return a copy to avoid concurrent modification issues
+	statusCopy := make([]ProbeStatus, len(serviceStatuses))
+	copy(statusCopy, serviceStatuses)
+	return statusCopy
+}
+
+// CheckServices probes the TLS endpoints of Services annotated with kubecertwatch.io/probe-port.
+func CheckServices(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Printf("Starting Service TLS probes in cluster %s...", cluster.Name)
+
+	listCtx, span := startListSpan(ctx, cluster.Name, "services")
+	services, err := cluster.Clientset.CoreV1().Services("").List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to list Services: %v", err)
+		return err
+	}
+
+	statuses := []ProbeStatus{}
+	owners := newOwnerResolver(ctx, cluster)
+	for _, service := range services.Items {
+		portValue, ok := service.Annotations[ProbePortAnnotation]
+		if !ok {
+			continue
+		}
+
+		p := policy.Resolve(policy.Object{Kind: "Service", Namespace: service.Namespace, Name: service.Name, Labels: service.Labels})
+		owner := owners.resolve(service.Namespace, service.Labels, service.Annotations)
+		silence := annotationSilence("Service", service.Namespace, service.Name, service.Annotations)
+		protocol := strings.ToLower(service.Annotations[ProbeProtocolAnnotation])
+		if protocol == "" {
+			protocol = "tls"
+		}
+		// unprobed is the status of a Service that cannot be probed
+		unprobed := func(status string) ProbeStatus {
+			return ProbeStatus{
+				Cluster:           cluster.Name,
+				Namespace:         service.Namespace,
+				Name:              service.Name,
+				SNI:               service.Annotations[ProbeSNIAnnotation],
+				Protocol:          protocol,
+				ExpirationDate:    "unknown",
+				Status:            status,
+				Owner:             owner,
+				Policy:            p.Name,
+				Muted:             p.Muted,
+				AnnotationSilence: silence,
+			}
+		}
+
+		port, err := resolveServicePort(service, portValue)
+		if err == nil && !config.IsProbeProtocol(protocol) {
+			err = fmt.Errorf("unsupported %s %q", ProbeProtocolAnnotation, protocol)
+		}
+		if err != nil {
+			log.Warnf("Service %s/%s has invalid probe annotations: %v", service.Namespace, service.Name, err)
+			statuses = append(statuses, unprobed("invalid probe settings"))
+			continue
+		}
+
+		hosts := serviceProbeHosts(service, cluster.Local)
+		if len(hosts) == 0 {
+			// Remote clusters are only reachable through external addresses
+			log.Warnf("Service %s/%s in cluster %s has no address to probe", service.Namespace, service.Name, cluster.Name)
+			statuses = append(statuses, unprobed("no reachable address"))
+			continue
+		}
+		for _, host := range hosts {
+			addr := net.JoinHostPort(host, strconv.Itoa(port))
+			status := probeEndpoint(ctx, cluster.Name, service.Namespace, service.Name, addr, service.Annotations[ProbeSNIAnnotation], protocol, p)
+			status.Owner = owner
+			status.AnnotationSilence = silence
+			log.Debugf("Service: %s/%s, Address: %s, Status: %s", service.Namespace, service.Name, addr, status.Status)
+
+			statuses = append(statuses, status)
+		}
+	}
+
+	record(results, &results.services, cluster.Name, statuses)
+
+	log.Println("Service TLS probes completed.")
+	return nil
+}
+
+// resolveServicePort resolves the probe-port annotation value to a port number, accepting port names.
+func resolveServicePort(service v1.Service, value string) (int, error) {
+	for _, port := range service.Spec.Ports {
+		if port.Name != "" && port.Name == value {
+			return int(port.Port), nil
+		}
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if port < 1 || port > 65535 {
+		return 0, strconv.ErrRange
+	}
+	return port, nil
+}
+
+// serviceProbeHosts returns the external addresses of a Service, preceded by its ClusterIP when the
+// Service belongs to the local cluster and is therefore reachable from the pod network.
+func serviceProbeHosts(service v1.Service, local bool) []string {
+	var hosts []string
+	if local && service.Spec.ClusterIP != "" && service.Spec.ClusterIP != v1.ClusterIPNone {
+		hosts = append(hosts, service.Spec.ClusterIP)
+	}
+	hosts = append(hosts, service.Spec.ExternalIPs...)
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			hosts = append(hosts, ingress.IP)
+		} else if ingress.Hostname != "" {
+			hosts = append(hosts, ingress.Hostname)
+		}
+	}
+	return hosts
+}
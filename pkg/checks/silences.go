@@ -0,0 +1,120 @@
+package checks
+
+import (
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+)
+
+// annotationSilence returns the silence set by the ignore-until annotation of an object. Invalid
+// values are logged and ignored.
+func annotationSilence(kind, namespace, name string, annotations map[string]string) *silences.Silence {
+	silence, err := silences.FromAnnotations(annotations)
+	if err != nil {
+		log.Warnf("Ignoring annotation of %s %s: %v", kind, objectName(namespace, name), err)
+		return nil
+	}
+	return silence
+}
+
+// objectName returns namespace/name, or the name of a cluster-scoped object.
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// Silence returns the silence in effect for the TLS secret, if any.
+func (s SecretStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: "Secret", Name: s.SecretName, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the cert-manager Certificate, if any.
+func (s CertManagerStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: "Certificate", Name: s.Certificate, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the Ingress, if any.
+func (s IngressStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: "Ingress", Name: s.IngressName, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the object holding the caBundle, if any.
+func (s WebhookStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Kind: s.Kind, Name: s.Name, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the Gateway, if any.
+func (s GatewayStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: "Gateway", Name: s.Gateway, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the Route, if any.
+func (s RouteStatus) Silence() (silences.Silence, bool) {
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: "Route", Name: s.Route, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// Silence returns the silence in effect for the probed Service or external endpoint, if any.
+func (s ProbeStatus) Silence() (silences.Silence, bool) {
+	kind := "Service"
+	if s.Namespace == "" {
+		kind = "External"
+	}
+	return silences.Find(silences.Object{Cluster: s.Cluster, Namespace: s.Namespace, Kind: kind, Name: s.Name, Owner: s.Owner}, s.AnnotationSilence)
+}
+
+// RefreshMetrics rebuilds the per-object metrics from the published statuses, for instance after the
+// silences changed.
+func RefreshMetrics() {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	updateMetrics()
+}
+
+// updateSilenceMetrics exports the silenced objects. statusLock must be held.
+func updateSilenceMetrics() {
+	seen := map[[5]string]bool{}
+	var series []metrics.Series
+	add := func(cluster, kind, namespace, name string, silence silences.Silence, silenced bool) {
+		key := [5]string{cluster, kind, namespace, name, silence.ID}
+		if !silenced || seen[key] {
+			return
+		}
+		seen[key] = true
+		series = append(series, metrics.Series{Labels: key[:], Value: 1})
+	}
+
+	for _, s := range secretStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Secret", s.Namespace, s.SecretName, silence, silenced)
+	}
+	for _, s := range certManagerStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Certificate", s.Namespace, s.Certificate, silence, silenced)
+	}
+	for _, s := range ingressStatus {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Ingress", s.Namespace, s.IngressName, silence, silenced)
+	}
+	for _, s := range webhookStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, s.Kind, "", s.Name, silence, silenced)
+	}
+	for _, s := range gatewayStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Gateway", s.Namespace, s.Gateway, silence, silenced)
+	}
+	for _, s := range routeStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Route", s.Namespace, s.Route, silence, silenced)
+	}
+	for _, s := range serviceStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "Service", s.Namespace, s.Name, silence, silenced)
+	}
+	for _, s := range externalStatuses {
+		silence, silenced := s.Silence()
+		add(s.Cluster, "External", "", s.Name, silence, silenced)
+	}
+	metrics.ObjectSilenced.Replace(series)
+}
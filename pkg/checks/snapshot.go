@@ -0,0 +1,78 @@
+package checks
+
+// Snapshot is a copy of every status collected for a set of clusters.
+type Snapshot struct {
+	Clusters    []string            `json:"clusters"`
+	Secrets     []SecretStatus      `json:"secrets"`
+	CertManager []CertManagerStatus `json:"certManager"`
+	Ingresses   []IngressStatus     `json:"ingresses"`
+	Webhooks    []WebhookStatus     `json:"webhooks"`
+	Gateways    []GatewayStatus     `json:"gateways"`
+	Routes      []RouteStatus       `json:"routes"`
+	Services    []ProbeStatus       `json:"services"`
+	External    []ProbeStatus       `json:"external"`
+}
+
+// TakeSnapshot returns a copy of the statuses of clusters.
+func TakeSnapshot(clusters []string) Snapshot {
+	selected := clusterSet(clusters)
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	return Snapshot{
+		Clusters:    append([]string(nil), clusters...),
+		Secrets:     retainClusterStatuses(secretStatuses, selected),
+		CertManager: retainClusterStatuses(certManagerStatuses, selected),
+		Ingresses:   retainClusterStatuses(ingressStatus, selected),
+		Webhooks:    retainClusterStatuses(webhookStatuses, selected),
+		Gateways:    retainClusterStatuses(gatewayStatuses, selected),
+		Routes:      retainClusterStatuses(routeStatuses, selected),
+		Services:    retainClusterStatuses(serviceStatuses, selected),
+		External:    retainClusterStatuses(externalStatuses, selected),
+	}
+}
+
+// ApplySnapshot replaces the statuses of the clusters in replaced and in the snapshot with the
+// statuses of the snapshot. Only records of the snapshot's own clusters are accepted.
+func ApplySnapshot(snapshot Snapshot, replaced []string) {
+	dropped := clusterSet(append(replaced, snapshot.Clusters...))
+	accepted := clusterSet(snapshot.Clusters)
+
+	statusLock.Lock()
+	defer statusLock.Unlock()
+	secretStatuses = replaceClusterStatuses(secretStatuses, dropped, accepted, snapshot.Secrets)
+	certManagerStatuses = replaceClusterStatuses(certManagerStatuses, dropped, accepted, snapshot.CertManager)
+	ingressStatus = replaceClusterStatuses(ingressStatus, dropped, accepted, snapshot.Ingresses)
+	webhookStatuses = replaceClusterStatuses(webhookStatuses, dropped, accepted, snapshot.Webhooks)
+	gatewayStatuses = replaceClusterStatuses(gatewayStatuses, dropped, accepted, snapshot.Gateways)
+	routeStatuses = replaceClusterStatuses(routeStatuses, dropped, accepted, snapshot.Routes)
+	serviceStatuses = replaceClusterStatuses(serviceStatuses, dropped, accepted, snapshot.Services)
+	externalStatuses = replaceClusterStatuses(externalStatuses, dropped, accepted, snapshot.External)
+	updateMetrics()
+}
+
+// replaceClusterStatuses drops the records of the dropped clusters from current and appends the
+// updated records that belong to an accepted cluster.
+func replaceClusterStatuses[T clusterScoped](current []T, dropped, accepted map[string]bool, updated []T) []T {
+	replaced := make([]T, 0, len(current)+len(updated))
+	for _, status := range current {
+		if !dropped[status.clusterName()] {
+			replaced = append(replaced, status)
+		}
+	}
+	for _, status := range updated {
+		if accepted[status.clusterName()] {
+			replaced = append(replaced, status)
+		}
+	}
+	return replaced
+}
+
+// clusterSet converts a list of cluster names into a set.
+func clusterSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
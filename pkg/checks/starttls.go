@@ -0,0 +1,269 @@
+package checks
+
+import (
+	"bufio"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// ldapStartTLSOID is the extended operation OID that requests a StartTLS upgrade (RFC 4511).
+const ldapStartTLSOID = "1.3.6.1.4.1.1466.20037"
+
+// mysqlClientSSL is the MySQL capability flag advertising TLS support.
+const mysqlClientSSL = 0x00000800
+
+// startTLS performs the plaintext negotiation that precedes the TLS handshake for protocol.
+// Raw TLS and HTTPS need no negotiation.
+func startTLS(conn net.Conn, protocol, serverName string) error {
+	switch protocol {
+	case "", "tls", "https":
+		return nil
+	case "smtp":
+		return startTLSSMTP(conn)
+	case "imap":
+		return startTLSIMAP(conn)
+	case "postgres":
+		return startTLSPostgres(conn)
+	case "mysql":
+		return startTLSMySQL(conn)
+	case "ldap":
+		return startTLSLDAP(conn)
+	case "xmpp":
+		return startTLSXMPP(conn, serverName)
+	default:
+		return fmt.Errorf("unsupported protocol %q", protocol)
+	}
+}
+
+// startTLSSMTP issues EHLO and STARTTLS (RFC 3207).
+func startTLSSMTP(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+	if err := readSMTPReply(reader, "220"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(conn, "EHLO kubecertwatch\r\n"); err != nil {
+		return err
+	}
+	if err := readSMTPReply(reader, "250"); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(conn, "STARTTLS\r\n"); err != nil {
+		return err
+	}
+	return readSMTPReply(reader, "220")
+}
+
+// readSMTPReply reads a possibly multi-line SMTP reply and checks its code.
+func readSMTPReply(reader *bufio.Reader, code string) error {
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if len(line) < 4 || line[:3] != code {
+			return fmt.Errorf("unexpected SMTP reply %q", strings.TrimSpace(line))
+		}
+		// "250-" continues a multi-line reply, "250 " ends it
+		if line[3] == ' ' {
+			return nil
+		}
+	}
+}
+
+// startTLSIMAP issues a tagged STARTTLS command (RFC 3501).
+func startTLSIMAP(conn net.Conn) error {
+	reader := bufio.NewReader(conn)
+	greeting, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(greeting, "* OK") {
+		return fmt.Errorf("unexpected IMAP greeting %q", strings.TrimSpace(greeting))
+	}
+	if _, err := io.WriteString(conn, "a001 STARTTLS\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "a001 ") {
+			if !strings.HasPrefix(line, "a001 OK") {
+				return fmt.Errorf("STARTTLS refused: %q", strings.TrimSpace(line))
+			}
+			return nil
+		}
+	}
+}
+
+// startTLSPostgres sends an SSLRequest message and expects the server to accept it.
+func startTLSPostgres(conn net.Conn) error {
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return err
+	}
+	if response[0] != 'S' {
+		return errors.New("server does not accept SSL connections")
+	}
+	return nil
+}
+
+// startTLSMySQL reads the initial handshake and answers with an SSL request packet.
+func startTLSMySQL(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return err
+	}
+	if len(payload) == 0 || payload[0] == 0xff {
+		return errors.New("server refused the connection")
+	}
+
+	// protocol version, NUL-terminated server version, connection id, auth data part 1 and a filler byte
+	versionEnd := strings.IndexByte(string(payload[1:]), 0)
+	offset := 1 + versionEnd + 1 + 4 + 8 + 1
+	if versionEnd < 0 || len(payload) < offset+2 {
+		return errors.New("malformed handshake packet")
+	}
+	capabilities := uint32(binary.LittleEndian.Uint16(payload[offset : offset+2]))
+	if capabilities&mysqlClientSSL == 0 {
+		return errors.New("server does not support SSL")
+	}
+
+	// capability flags, max packet size, character set and 23 reserved bytes
+	request := make([]byte, 4+32)
+	request[0], request[3] = 32, header[3]+1
+	binary.LittleEndian.PutUint32(request[4:8], mysqlClientSSL|0x00000200|0x00008000)
+	binary.LittleEndian.PutUint32(request[8:12], 1<<24)
+	request[12] = 33
+	_, err := conn.Write(request)
+	return err
+}
+
+// startTLSLDAP sends a StartTLS extended request (RFC 4511) and checks the result code.
+func startTLSLDAP(conn net.Conn) error {
+	oid := []byte(ldapStartTLSOID)
+	extendedRequest := append([]byte{0x80, byte(len(oid))}, oid...)
+	protocolOp := append([]byte{0x77, byte(len(extendedRequest))}, extendedRequest...)
+	message := append([]byte{0x02, 0x01, 0x01}, protocolOp...)
+	request := append([]byte{0x30, byte(len(message))}, message...)
+	if _, err := conn.Write(request); err != nil {
+		return err
+	}
+
+	response, err := readBERMessage(conn)
+	if err != nil {
+		return err
+	}
+
+	var envelope, messageID, extendedResponse, resultCode asn1.RawValue
+	if _, err := asn1.Unmarshal(response, &envelope); err != nil {
+		return err
+	}
+	rest, err := asn1.Unmarshal(envelope.Bytes, &messageID)
+	if err != nil {
+		return err
+	}
+	if _, err := asn1.Unmarshal(rest, &extendedResponse); err != nil {
+		return err
+	}
+	if _, err := asn1.Unmarshal(extendedResponse.Bytes, &resultCode); err != nil {
+		return err
+	}
+	if len(resultCode.Bytes) != 1 || resultCode.Bytes[0] != 0 {
+		return fmt.Errorf("StartTLS refused with result code %v", resultCode.Bytes)
+	}
+	return nil
+}
+
+// readBERMessage reads a single BER-encoded element from reader.
+func readBERMessage(reader io.Reader) ([]byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	if header[1]&0x80 != 0 {
+		lengthBytes := make([]byte, header[1]&0x7f)
+		if len(lengthBytes) == 0 || len(lengthBytes) > 4 {
+			return nil, errors.New("unsupported BER length encoding")
+		}
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		header = append(header, lengthBytes...)
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// startTLSXMPP opens a client stream and negotiates STARTTLS (RFC 6120).
+func startTLSXMPP(conn net.Conn, serverName string) error {
+	_, err := fmt.Fprintf(conn, "<?xml version='1.0'?><stream:stream to='%s' xmlns='jabber:client' "+
+		"xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>", serverName)
+	if err != nil {
+		return err
+	}
+	features, err := readUntil(conn, "</stream:features>")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(features, "urn:ietf:params:xml:ns:xmpp-tls") {
+		return errors.New("server does not offer STARTTLS")
+	}
+
+	if _, err := io.WriteString(conn, "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"); err != nil {
+		return err
+	}
+	reply, err := readUntil(conn, ">")
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(reply, "<proceed") {
+		return fmt.Errorf("STARTTLS refused: %q", reply)
+	}
+	return nil
+}
+
+// readUntil reads from reader byte by byte until marker has been received, so that no bytes
+// belonging to the TLS handshake are consumed.
+func readUntil(reader io.Reader, marker string) (string, error) {
+	var received strings.Builder
+	buf := make([]byte, 1)
+	for received.Len() < 64*1024 {
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return received.String(), err
+		}
+		received.WriteByte(buf[0])
+		if strings.HasSuffix(received.String(), marker) {
+			return received.String(), nil
+		}
+	}
+	return received.String(), errors.New("response too large")
+}
@@ -0,0 +1,289 @@
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readLine reads a CRLF-terminated command sent by the client and checks it.
+func readLine(reader *bufio.Reader, want string) error {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if line != want+"\r\n" {
+		return fmt.Errorf("got %q, want %q", line, want)
+	}
+	return nil
+}
+
+// ber encodes a BER element with tag and content, using the long length form when required.
+func ber(tag byte, content ...[]byte) []byte {
+	body := bytes.Join(content, nil)
+	if len(body) < 0x80 {
+		return append([]byte{tag, byte(len(body))}, body...)
+	}
+	var length []byte
+	for n := len(body); n > 0; n >>= 8 {
+		length = append([]byte{byte(n)}, length...)
+	}
+	header := append([]byte{tag, 0x80 | byte(len(length))}, length...)
+	return append(header, body...)
+}
+
+// ldapResponse encodes the ExtendedResponse to a StartTLS request with resultCode and diagnostic.
+func ldapResponse(resultCode byte, diagnostic string) []byte {
+	return ber(0x30,
+		ber(0x02, []byte{0x01}),
+		ber(0x78,
+			ber(0x0a, []byte{resultCode}),
+			ber(0x04),
+			ber(0x04, []byte(diagnostic)),
+		),
+	)
+}
+
+// mysqlHandshake encodes an initial handshake packet advertising capabilities.
+func mysqlHandshake(capabilities uint16) []byte {
+	payload := []byte{10}
+	payload = append(payload, "8.0.36\x00"...)
+	payload = append(payload, 1, 0, 0, 0)    // connection id
+	payload = append(payload, "abcdefgh"...) // auth data part 1
+	payload = append(payload, 0)             // filler
+	payload = binary.LittleEndian.AppendUint16(payload, capabilities)
+	payload = append(payload, 33, 2, 0) // character set and status flags
+	header := []byte{byte(len(payload)), byte(len(payload) >> 8), byte(len(payload) >> 16), 0}
+	return append(header, payload...)
+}
+
+func TestStartTLS(t *testing.T) {
+	smtpServer := func(reply string) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			reader := bufio.NewReader(conn)
+			if _, err := io.WriteString(conn, "220 mail.example.com ESMTP\r\n"); err != nil {
+				return err
+			}
+			if err := readLine(reader, "EHLO kubecertwatch"); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(conn, "250-mail.example.com\r\n250-SIZE 10240000\r\n250 STARTTLS\r\n"); err != nil {
+				return err
+			}
+			if err := readLine(reader, "STARTTLS"); err != nil {
+				return err
+			}
+			_, err := io.WriteString(conn, reply)
+			return err
+		}
+	}
+	imapServer := func(greeting, reply string) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			if _, err := io.WriteString(conn, greeting); err != nil {
+				return err
+			}
+			if !strings.HasPrefix(greeting, "* OK") {
+				return nil
+			}
+			if err := readLine(bufio.NewReader(conn), "a001 STARTTLS"); err != nil {
+				return err
+			}
+			_, err := io.WriteString(conn, "* CAPABILITY IMAP4rev1 STARTTLS\r\n"+reply)
+			return err
+		}
+	}
+	postgresServer := func(reply byte) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			request := make([]byte, 8)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return err
+			}
+			if length, code := binary.BigEndian.Uint32(request[0:4]), binary.BigEndian.Uint32(request[4:8]); length != 8 || code != 80877103 {
+				return fmt.Errorf("got SSLRequest length %d code %d", length, code)
+			}
+			_, err := conn.Write([]byte{reply})
+			return err
+		}
+	}
+	mysqlServer := func(packet []byte, expectRequest bool) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			if _, err := conn.Write(packet); err != nil {
+				return err
+			}
+			if !expectRequest {
+				return nil
+			}
+			request := make([]byte, 36)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return err
+			}
+			if length := int(request[0]) | int(request[1])<<8 | int(request[2])<<16; length != 32 {
+				return fmt.Errorf("got SSL request payload of %d bytes, want 32", length)
+			}
+			if request[3] != 1 {
+				return fmt.Errorf("got sequence id %d, want 1", request[3])
+			}
+			if capabilities := binary.LittleEndian.Uint32(request[4:8]); capabilities&mysqlClientSSL == 0 {
+				return fmt.Errorf("SSL request does not set CLIENT_SSL: %#x", capabilities)
+			}
+			return nil
+		}
+	}
+	ldapServer := func(response []byte) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			request, err := readBERMessage(conn)
+			if err != nil {
+				return err
+			}
+			if !bytes.Contains(request, []byte(ldapStartTLSOID)) {
+				return fmt.Errorf("request %x does not name the StartTLS OID", request)
+			}
+			_, err = conn.Write(response)
+			return err
+		}
+	}
+	xmppServer := func(features, reply string) func(net.Conn) error {
+		return func(conn net.Conn) error {
+			header, err := readUntil(conn, "version='1.0'>")
+			if err != nil {
+				return err
+			}
+			if !strings.Contains(header, "to='xmpp.example.com'") {
+				return fmt.Errorf("stream header %q does not address the server", header)
+			}
+			if _, err := io.WriteString(conn, "<?xml version='1.0'?><stream:stream from='xmpp.example.com' "+
+				"xmlns='jabber:client' xmlns:stream='http://etherx.jabber.org/streams' version='1.0'>"+
+				"<stream:features>"+features+"</stream:features>"); err != nil {
+				return err
+			}
+			if reply == "" {
+				return nil
+			}
+			if _, err := readUntil(conn, "xmpp-tls'/>"); err != nil {
+				return err
+			}
+			_, err = io.WriteString(conn, reply)
+			return err
+		}
+	}
+	xmppTLS := "<starttls xmlns='urn:ietf:params:xml:ns:xmpp-tls'><required/></starttls>"
+
+	tests := []struct {
+		name     string
+		protocol string
+		server   func(net.Conn) error
+		wantErr  string
+	}{
+		{name: "raw TLS", protocol: "tls"},
+		{name: "unsupported protocol", protocol: "ftp", wantErr: `unsupported protocol "ftp"`},
+
+		{name: "SMTP accepted", protocol: "smtp", server: smtpServer("220 2.0.0 Ready to start TLS\r\n")},
+		{name: "SMTP refused", protocol: "smtp", server: smtpServer("454 4.7.0 TLS not available\r\n"), wantErr: "unexpected SMTP reply"},
+		{name: "SMTP rejected greeting", protocol: "smtp", server: func(conn net.Conn) error {
+			_, err := io.WriteString(conn, "554 No service\r\n")
+			return err
+		}, wantErr: `unexpected SMTP reply "554 No service"`},
+
+		{name: "IMAP accepted", protocol: "imap", server: imapServer("* OK IMAP4rev1 ready\r\n", "a001 OK Begin TLS negotiation now\r\n")},
+		{name: "IMAP refused", protocol: "imap", server: imapServer("* OK IMAP4rev1 ready\r\n", "a001 NO STARTTLS unavailable\r\n"), wantErr: "STARTTLS refused"},
+		{name: "IMAP rejected greeting", protocol: "imap", server: imapServer("* BYE too many connections\r\n", ""), wantErr: "unexpected IMAP greeting"},
+
+		{name: "Postgres accepted", protocol: "postgres", server: postgresServer('S')},
+		{name: "Postgres refused", protocol: "postgres", server: postgresServer('N'), wantErr: "does not accept SSL"},
+
+		{name: "MySQL accepted", protocol: "mysql", server: mysqlServer(mysqlHandshake(0xffff), true)},
+		{name: "MySQL without CLIENT_SSL", protocol: "mysql", server: mysqlServer(mysqlHandshake(0xffff&^mysqlClientSSL), false), wantErr: "does not support SSL"},
+		{name: "MySQL error packet", protocol: "mysql", server: mysqlServer([]byte{3, 0, 0, 0, 0xff, 0x10, 0x04}, false), wantErr: "refused the connection"},
+		{name: "MySQL truncated handshake", protocol: "mysql", server: mysqlServer([]byte{7, 0, 0, 0, 10, '8', '.', '0', 0, 1, 0}, false), wantErr: "malformed handshake packet"},
+
+		{name: "LDAP accepted", protocol: "ldap", server: ldapServer(ldapResponse(0, ""))},
+		{name: "LDAP accepted with long-form length", protocol: "ldap", server: ldapServer(ldapResponse(0, strings.Repeat("x", 300)))},
+		{name: "LDAP refused", protocol: "ldap", server: ldapServer(ldapResponse(2, "StartTLS not supported")), wantErr: "result code [2]"},
+
+		{name: "XMPP accepted", protocol: "xmpp", server: xmppServer(xmppTLS, "<proceed xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>")},
+		{name: "XMPP without STARTTLS", protocol: "xmpp", server: xmppServer("<mechanisms/>", ""), wantErr: "does not offer STARTTLS"},
+		{name: "XMPP refused", protocol: "xmpp", server: xmppServer(xmppTLS, "<failure xmlns='urn:ietf:params:xml:ns:xmpp-tls'/>"), wantErr: "STARTTLS refused"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			deadline := time.Now().Add(5 * time.Second)
+			_ = client.SetDeadline(deadline)
+			_ = server.SetDeadline(deadline)
+
+			served := make(chan error, 1)
+			go func() {
+				if tt.server == nil {
+					served <- nil
+					return
+				}
+				served <- tt.server(server)
+			}()
+
+			err := startTLS(client, tt.protocol, "xmpp.example.com")
+			client.Close()
+			serverErr := <-served
+			server.Close()
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("startTLS failed: %v", err)
+				}
+				if serverErr != nil {
+					t.Fatalf("server side: %v", serverErr)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadBERMessage(t *testing.T) {
+	long := bytes.Repeat([]byte{0xab}, 300)
+	tests := []struct {
+		name    string
+		input   []byte
+		want    []byte
+		wantErr error
+	}{
+		{name: "short form", input: []byte{0x30, 0x03, 0x02, 0x01, 0x01}, want: []byte{0x30, 0x03, 0x02, 0x01, 0x01}},
+		{name: "empty body", input: []byte{0x04, 0x00}, want: []byte{0x04, 0x00}},
+		{name: "long form with one length byte", input: append([]byte{0x04, 0x81, 0x80}, long[:128]...), want: append([]byte{0x04, 0x81, 0x80}, long[:128]...)},
+		{name: "long form with two length bytes", input: append([]byte{0x04, 0x82, 0x01, 0x2c}, long...), want: append([]byte{0x04, 0x82, 0x01, 0x2c}, long...)},
+		{name: "trailing bytes are left unread", input: []byte{0x04, 0x01, 0xff, 0x16, 0x03}, want: []byte{0x04, 0x01, 0xff}},
+		{name: "indefinite length", input: []byte{0x30, 0x80, 0x00, 0x00}, wantErr: errors.New("unsupported BER length encoding")},
+		{name: "more than four length bytes", input: []byte{0x30, 0x85, 0, 0, 0, 0, 1, 0}, wantErr: errors.New("unsupported BER length encoding")},
+		{name: "truncated length", input: []byte{0x30, 0x82, 0x01}, wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated body", input: []byte{0x30, 0x05, 0x02, 0x01}, wantErr: io.ErrUnexpectedEOF},
+		{name: "truncated header", input: []byte{0x30}, wantErr: io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBERMessage(bytes.NewReader(tt.input))
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readBERMessage failed: %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Fatalf("got %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package checks
+
+import (
+	"context"
+
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startListSpan starts the span of a List call for resource in cluster.
+func startListSpan(ctx context.Context, cluster, resource string) (context.Context, trace.Span) {
+	return telemetry.StartSpan(ctx, "List "+resource,
+		attribute.String("cluster", cluster),
+		attribute.String("resource", resource),
+	)
+}
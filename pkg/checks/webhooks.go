@@ -0,0 +1,322 @@
+package checks
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WebhookStatus represents the status of a single CA certificate embedded in a caBundle.
+type WebhookStatus struct {
+	Cluster           string
+	Kind              string
+	Name              string
+	Webhook           string
+	CASubject         string
+	CAFingerprint     string
+	ExpirationDate    string
+	NotAfter          time.Time
+	DaysUntil         int
+	Status            string
+	ServiceStatus     string
+	Owner             string
+	Policy            string
+	Muted             bool
+	AnnotationSilence *silences.Silence
+}
+
+// caBundleTarget is a caBundle together with the endpoint that is expected to serve a certificate signed by it.
+type caBundleTarget struct {
+	kind       string
+	name       string
+	webhook    string
+	labels     map[string]string
+	owner      string
+	silence    *silences.Silence
+	caBundle   []byte
+	address    string
+	serverName string
+	isService  bool
+}
+
+var (
+	webhookStatuses []WebhookStatus
+)
+
+// newCABundleTarget creates the target of a caBundle held by the cluster-scoped object kind/name. The
+// owner and any annotation silence are read from the object's labels and annotations.
+func newCABundleTarget(kind, name, webhook string, labels, annotations map[string]string, caBundle []byte) caBundleTarget {
+	return caBundleTarget{
+		kind:     kind,
+		name:     name,
+		webhook:  webhook,
+		labels:   labels,
+		owner:    ownerOf(labels, annotations),
+		silence:  annotationSilence(kind, "", name, annotations),
+		caBundle: caBundle,
+	}
+}
+
+// GetWebhookStatuses returns a snapshot of the current caBundle statuses.
+func GetWebhookStatuses() []WebhookStatus {
+	statusLock.Lock()
+	defer statusLock.Unlock()
+
+	// Return a copy to avoid concurrent modification issues
+	statusCopy := make([]WebhookStatus, len(webhookStatuses))
+	copy(statusCopy, webhookStatuses)
+	return statusCopy
+}
+
+// CheckWebhookCABundles checks the caBundle of every admission webhook, APIService and CRD conversion webhook.
+func CheckWebhookCABundles(ctx context.Context, cluster k8s.Cluster, results *Results) error {
+	log.Printf("Starting webhook caBundle checks in cluster %s...", cluster.Name)
+	clientset := cluster.Clientset
+
+	extClient, err := k8s.ConnectToAPIExtensions(cluster.Config)
+	if err != nil {
+		return err
+	}
+	aggClient, err := k8s.ConnectToAPIRegistration(cluster.Config)
+	if err != nil {
+		return err
+	}
+
+	var targets []caBundleTarget
+
+	listCtx, span := startListSpan(ctx, cluster.Name, "validatingwebhookconfigurations")
+	validating, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to list ValidatingWebhookConfigurations: %v", err)
+		return err
+	}
+	for _, cfg := range validating.Items {
+		for _, webhook := range cfg.Webhooks {
+			target := newCABundleTarget("ValidatingWebhookConfiguration", cfg.Name, webhook.Name, cfg.Labels, cfg.Annotations, webhook.ClientConfig.CABundle)
+			if svc := webhook.ClientConfig.Service; svc != nil {
+				target.address, target.serverName = serviceAddress(svc.Namespace, svc.Name, svc.Port)
+				target.isService = true
+			} else if webhook.ClientConfig.URL != nil {
+				target.address, target.serverName = urlAddress(*webhook.ClientConfig.URL)
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	listCtx, span = startListSpan(ctx, cluster.Name, "mutatingwebhookconfigurations")
+	mutating, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to list MutatingWebhookConfigurations: %v", err)
+		return err
+	}
+	for _, cfg := range mutating.Items {
+		for _, webhook := range cfg.Webhooks {
+			target := newCABundleTarget("MutatingWebhookConfiguration", cfg.Name, webhook.Name, cfg.Labels, cfg.Annotations, webhook.ClientConfig.CABundle)
+			if svc := webhook.ClientConfig.Service; svc != nil {
+				target.address, target.serverName = serviceAddress(svc.Namespace, svc.Name, svc.Port)
+				target.isService = true
+			} else if webhook.ClientConfig.URL != nil {
+				target.address, target.serverName = urlAddress(*webhook.ClientConfig.URL)
+			}
+			targets = append(targets, target)
+		}
+	}
+
+	listCtx, span = startListSpan(ctx, cluster.Name, "apiservices")
+	apiServices, err := aggClient.ApiregistrationV1().APIServices().List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to list APIServices: %v", err)
+		return err
+	}
+	for _, apiService := range apiServices.Items {
+		// Local APIServices are served by kube-apiserver itself and insecure ones never verify the bundle
+		if apiService.Spec.Service == nil || apiService.Spec.InsecureSkipTLSVerify {
+			continue
+		}
+		target := newCABundleTarget("APIService", apiService.Name, "-", apiService.Labels, apiService.Annotations, apiService.Spec.CABundle)
+		target.isService = true
+		target.address, target.serverName = serviceAddress(apiService.Spec.Service.Namespace, apiService.Spec.Service.Name, apiService.Spec.Service.Port)
+		targets = append(targets, target)
+	}
+
+	listCtx, span = startListSpan(ctx, cluster.Name, "customresourcedefinitions")
+	crds, err := extClient.ApiextensionsV1().CustomResourceDefinitions().List(listCtx, metav1.ListOptions{})
+	telemetry.EndSpan(span, err)
+	if err != nil {
+		log.Errorf("Failed to list CustomResourceDefinitions: %v", err)
+		return err
+	}
+	for _, crd := range crds.Items {
+		conversion := crd.Spec.Conversion
+		if conversion == nil || conversion.Webhook == nil || conversion.Webhook.ClientConfig == nil {
+			continue
+		}
+		clientConfig := conversion.Webhook.ClientConfig
+		target := newCABundleTarget("CustomResourceDefinition", crd.Name, "conversion", crd.Labels, crd.Annotations, clientConfig.CABundle)
+		if svc := clientConfig.Service; svc != nil {
+			target.address, target.serverName = serviceAddress(svc.Namespace, svc.Name, svc.Port)
+			target.isService = true
+		} else if clientConfig.URL != nil {
+			target.address, target.serverName = urlAddress(*clientConfig.URL)
+		}
+		targets = append(targets, target)
+	}
+	log.Debugf("Found %d caBundle references in the cluster", len(targets))
+
+	statuses := []WebhookStatus{}
+	for _, target := range targets {
+		targetStatuses := checkCABundleTarget(ctx, cluster, target)
+		for i := range targetStatuses {
+			targetStatuses[i].Owner = target.owner
+			targetStatuses[i].AnnotationSilence = target.silence
+		}
+		statuses = append(statuses, targetStatuses...)
+	}
+
+	record(results, &results.webhooks, cluster.Name, statuses)
+
+	log.Println("Webhook caBundle checks completed.")
+	return nil
+}
+
+// checkCABundleTarget decodes the caBundle of target and returns one status per embedded CA certificate.
+// Service references are only probed in the local cluster, where cluster DNS resolves them.
+func checkCABundleTarget(ctx context.Context, cluster k8s.Cluster, target caBundleTarget) []WebhookStatus {
+	p := policy.Resolve(policy.Object{Kind: target.kind, Name: target.name, Labels: target.labels})
+
+	if len(target.caBundle) == 0 {
+		// URL webhooks without a caBundle are verified against the API server's system roots
+		if !target.isService {
+			return nil
+		}
+		log.Warnf("%s %s (%s) has no caBundle", target.kind, target.name, target.webhook)
+		return []WebhookStatus{{
+			Cluster:        cluster.Name,
+			Kind:           target.kind,
+			Name:           target.name,
+			Webhook:        target.webhook,
+			ExpirationDate: "unknown",
+			Status:         "missing caBundle",
+			ServiceStatus:  "not probed",
+			Policy:         p.Name,
+			Muted:          p.Muted,
+		}}
+	}
+
+	caCerts, err := parseCertificates(ctx, target.caBundle)
+	if err != nil {
+		log.Errorf("Failed to parse caBundle of %s %s (%s): %v", target.kind, target.name, target.webhook, err)
+		return []WebhookStatus{{
+			Cluster:        cluster.Name,
+			Kind:           target.kind,
+			Name:           target.name,
+			Webhook:        target.webhook,
+			ExpirationDate: "unknown",
+			Status:         "error parsing cert",
+			ServiceStatus:  "not probed",
+			Policy:         p.Name,
+			Muted:          p.Muted,
+		}}
+	}
+
+	serviceStatus := "not probed"
+	if config.Current().WebhookProbeServices && target.address != "" && (cluster.Local || !target.isService) {
+		roots := x509.NewCertPool()
+		for _, caCert := range caCerts {
+			roots.AddCert(caCert)
+		}
+		serviceStatus = verifyServingChain(ctx, target.address, target.serverName, roots)
+	}
+
+	statuses := make([]WebhookStatus, 0, len(caCerts))
+	for _, caCert := range caCerts {
+		daysUntil, status := expiryStatus(caCert.NotAfter, p)
+		if status != "valid" {
+			log.Warnf("CA %q in %s %s (%s) is %s", caCert.Subject.CommonName, target.kind, target.name, target.webhook, status)
+		}
+		statuses = append(statuses, WebhookStatus{
+			Cluster:        cluster.Name,
+			Kind:           target.kind,
+			Name:           target.name,
+			Webhook:        target.webhook,
+			CASubject:      caCert.Subject.CommonName,
+			CAFingerprint:  fmt.Sprintf("%x", sha256.Sum256(caCert.Raw)),
+			ExpirationDate: caCert.NotAfter.Format("2006-01-02"),
+			NotAfter:       caCert.NotAfter,
+			DaysUntil:      daysUntil,
+			Status:         status,
+			ServiceStatus:  serviceStatus,
+			Policy:         p.Name,
+			Muted:          p.Muted,
+		})
+	}
+	return statuses
+}
+
+// verifyServingChain dials addr and checks that the served certificate is current and chains to roots.
+func verifyServingChain(ctx context.Context, addr, serverName string, roots *x509.CertPool) string {
+	chain, err := fetchPeerCertificates(ctx, addr, serverName, "https")
+	if err != nil {
+		log.Warnf("Failed to probe %s: %v", addr, err)
+		return "unreachable"
+	}
+
+	leaf := chain[0]
+	if time.Now().After(leaf.NotAfter) {
+		return "expired"
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	if err != nil {
+		log.Warnf("Certificate served by %s does not verify against its caBundle: %v", addr, err)
+		return "untrusted"
+	}
+	return "valid"
+}
+
+// serviceAddress returns the dial address and TLS server name the API server uses for a Service reference.
+func serviceAddress(namespace, name string, port *int32) (string, string) {
+	servicePort := int32(443)
+	if port != nil {
+		servicePort = *port
+	}
+	serverName := fmt.Sprintf("%s.%s.svc", name, namespace)
+	return net.JoinHostPort(serverName, strconv.Itoa(int(servicePort))), serverName
+}
+
+// urlAddress returns the dial address and TLS server name for a webhook URL.
+func urlAddress(rawURL string) (string, string) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		log.Warnf("Ignoring invalid webhook URL %q: %v", rawURL, err)
+		return "", ""
+	}
+	port := parsed.Port()
+	if port == "" {
+		port = "443"
+	}
+	return net.JoinHostPort(parsed.Hostname(), port), parsed.Hostname()
+}
@@ -2,38 +2,242 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
 // AppConfig structure for environment-based configurations.
 type AppConfig struct {
-	Debug        bool   `json:"debug"`
-	MetricsPort  int    `json:"metricsPort"`
-	CronSchedule string `json:"cronSchedule"`
-	ClusterName  string `json:"clusterName"`
-	KubeConfig   string `json:"kubeConfig"`
+	Debug                bool              `json:"debug"`
+	MetricsPort          int               `json:"metricsPort"`
+	MetricsPrefix        string            `json:"metricsPrefix"`
+	MetricsConstLabels   map[string]string `json:"metricsConstLabels"`
+	CronSchedule         string            `json:"cronSchedule"`
+	ExpiryWarningDays    int               `json:"expiryWarningDays"`
+	ClusterName          string            `json:"clusterName"`
+	KubeConfig           string            `json:"kubeConfig"`
+	WebhookProbeServices bool              `json:"webhookProbeServices"`
+	ExternalTargets      []ProbeTarget     `json:"externalTargets"`
+	ProbeLegacyTLS       bool              `json:"probeLegacyTLS"`
+	RevocationCheck      bool              `json:"revocationCheck"`
+
+	KubeConfigContexts     []string            `json:"kubeConfigContexts"`
+	ClusterKubeConfigs     []ClusterKubeConfig `json:"clusterKubeConfigs"`
+	ClusterSecretNamespace string              `json:"clusterSecretNamespace"`
+
+	HubURL          string        `json:"hubURL"`
+	HubEnabled      bool          `json:"hubEnabled"`
+	HubSharedSecret string        `json:"hubSharedSecret,omitempty"`
+	HubAgentKeysDir string        `json:"hubAgentKeysDir"`
+	HubStaleAfter   time.Duration `json:"hubStaleAfter"`
+
+	LeaderElection              bool          `json:"leaderElection"`
+	LeaderElectionNamespace     string        `json:"leaderElectionNamespace"`
+	LeaderElectionLeaseName     string        `json:"leaderElectionLeaseName"`
+	LeaderElectionLeaseDuration time.Duration `json:"leaderElectionLeaseDuration"`
+	LeaderElectionRenewDeadline time.Duration `json:"leaderElectionRenewDeadline"`
+	LeaderElectionRetryPeriod   time.Duration `json:"leaderElectionRetryPeriod"`
+
+	ReadinessMaxAge time.Duration `json:"readinessMaxAge"`
+
+	PrometheusRuleEnabled   bool              `json:"prometheusRuleEnabled"`
+	PrometheusRuleNamespace string            `json:"prometheusRuleNamespace"`
+	PrometheusRuleName      string            `json:"prometheusRuleName"`
+	PrometheusRuleLabels    map[string]string `json:"prometheusRuleLabels"`
+
+	ReportsEnabled   bool   `json:"reportsEnabled"`
+	ReportsMode      string `json:"reportsMode"`
+	ReportsNamespace string `json:"reportsNamespace"`
+
+	OTLPEndpoint       string            `json:"otlpEndpoint"`
+	OTLPProtocol       string            `json:"otlpProtocol"`
+	OTLPHeaders        map[string]string `json:"otlpHeaders,omitempty"`
+	OTLPMetrics        bool              `json:"otlpMetrics"`
+	OTLPTraces         bool              `json:"otlpTraces"`
+	OTLPExportInterval time.Duration     `json:"otlpExportInterval"`
+
+	Receivers []Receiver `json:"receivers"`
+	Route     *Route     `json:"route,omitempty"`
+}
+
+// ClusterKubeConfig is a remote cluster monitored through a kubeconfig file.
+type ClusterKubeConfig struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// ProbeTarget is a TLS endpoint outside the cluster that is probed on the cron schedule.
+type ProbeTarget struct {
+	Address        string `json:"address"`
+	SNI            string `json:"sni,omitempty"`
+	Protocol       string `json:"protocol,omitempty"`
+	ExpectedIssuer string `json:"expectedIssuer,omitempty"`
+	Owner          string `json:"owner,omitempty"`
+}
+
+// probeProtocols lists the protocol hints understood by the TLS prober. "tls" is a raw handshake,
+// the others perform the protocol's STARTTLS-style upgrade first.
+var probeProtocols = []string{"tls", "https", "smtp", "imap", "postgres", "mysql", "ldap", "xmpp"}
+
+// IsProbeProtocol reports whether protocol is a protocol hint understood by the TLS prober.
+func IsProbeProtocol(protocol string) bool {
+	for _, supported := range probeProtocols {
+		if protocol == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Patterns of valid Prometheus metric and label names.
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// current holds the active configuration. It is replaced as a whole on reload, so that readers
+// always see one consistent version.
+var current atomic.Pointer[AppConfig]
+
+// Current returns the active configuration. Callers must not modify it.
+func Current() *AppConfig {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	return &AppConfig{}
+}
+
+// Options are the command-line options that control how the configuration is loaded.
+type Options struct {
+	ConfigFile  string
+	PrintConfig bool
 }
 
-// CFG is the global configuration object.
-var CFG AppConfig
+// loadedArgs and loadedOptions are the command line of the last LoadConfiguration call, reused on reload.
+var (
+	loadedArgs    []string
+	loadedOptions Options
+)
 
-// LoadConfiguration loads configuration from environment variables.
-func LoadConfiguration() {
-	CFG.Debug = parseEnvBool("DEBUG", false)
-	CFG.MetricsPort = parseEnvInt("METRICS_PORT", 9990)
-	CFG.CronSchedule = getEnvOrDefault("CRON_SCHEDULE", "0 */12 * * *")
-	CFG.ClusterName = getEnvOrDefault("CLUSTER_NAME", "")
-	CFG.KubeConfig = getEnvOrDefault("KUBECONFIG", "")
+// LoadConfiguration builds the configuration from, in increasing order of precedence, the defaults,
+// environment variables, the config file and command-line flags.
+func LoadConfiguration(args []string) (Options, error) {
+	cfg, opts, err := load(args)
+	if err != nil {
+		return opts, err
+	}
+	loadedArgs, loadedOptions = args, opts
+	current.Store(&cfg)
 
-	if CFG.Debug {
-		log.Printf("Configuration Loaded: %+v\n", CFG)
+	if cfg.Debug {
+		log.Printf("Configuration Loaded: %+v\n", cfg.Redacted())
 	}
+	return opts, nil
+}
+
+// load reads every configuration layer.
+func load(args []string) (AppConfig, Options, error) {
+	cfg := loadEnv()
+	opts := Options{ConfigFile: os.Getenv("CONFIG_FILE")}
+
+	flags := newFlagSet(&cfg, &opts)
+	if err := flags.Parse(args); err != nil {
+		return cfg, opts, err
+	}
+	if flags.NArg() > 0 {
+		return cfg, opts, fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+	if opts.ConfigFile != "" {
+		if err := loadFile(opts.ConfigFile, &cfg); err != nil {
+			return cfg, opts, fmt.Errorf("loading config file %s: %w", opts.ConfigFile, err)
+		}
+		// The first pass only located the file; parse again so that flags take precedence over it
+		if err := flags.Parse(args); err != nil {
+			return cfg, opts, err
+		}
+	}
+	return cfg, opts, nil
+}
+
+// loadEnv returns the defaults overridden by the environment variables that are set.
+func loadEnv() AppConfig {
+	var cfg AppConfig
+	cfg.Debug = parseEnvBool("DEBUG", false)
+	cfg.MetricsPort = parseEnvInt("METRICS_PORT", 9990)
+	cfg.MetricsPrefix = getEnvOrDefault("METRICS_PREFIX", "kubecertwatch_")
+	cfg.MetricsConstLabels = parseEnvLabels("METRICS_CONST_LABELS")
+	cfg.CronSchedule = getEnvOrDefault("CRON_SCHEDULE", "0 */12 * * *")
+	cfg.ExpiryWarningDays = parseEnvInt("EXPIRY_WARNING_DAYS", 7)
+	cfg.ClusterName = getEnvOrDefault("CLUSTER_NAME", "")
+	cfg.KubeConfig = getEnvOrDefault("KUBECONFIG", "")
+	cfg.WebhookProbeServices = parseEnvBool("WEBHOOK_PROBE_SERVICES", false)
+	cfg.ExternalTargets = parseEnvTargets("EXTERNAL_TARGETS")
+	cfg.ProbeLegacyTLS = parseEnvBool("PROBE_LEGACY_TLS", true)
+	cfg.RevocationCheck = parseEnvBool("REVOCATION_CHECK", false)
+	cfg.KubeConfigContexts = parseEnvList("KUBECONFIG_CONTEXTS")
+	cfg.ClusterKubeConfigs = parseEnvClusterKubeConfigs("CLUSTER_KUBECONFIGS")
+	cfg.ClusterSecretNamespace = getEnvOrDefault("CLUSTER_SECRET_NAMESPACE", "")
+	cfg.HubURL = getEnvOrDefault("HUB_URL", "")
+	cfg.HubEnabled = parseEnvBool("HUB_ENABLED", false)
+	cfg.HubSharedSecret = os.Getenv("HUB_SHARED_SECRET")
+	cfg.HubAgentKeysDir = getEnvOrDefault("HUB_AGENT_KEYS_DIR", "")
+	cfg.HubStaleAfter = parseEnvDuration("HUB_STALE_AFTER", 25*time.Hour)
+	cfg.LeaderElection = parseEnvBool("LEADER_ELECTION", false)
+	cfg.LeaderElectionNamespace = getEnvOrDefault("LEADER_ELECTION_NAMESPACE", "")
+	cfg.LeaderElectionLeaseName = getEnvOrDefault("LEADER_ELECTION_LEASE_NAME", "kubecertwatch")
+	cfg.LeaderElectionLeaseDuration = parseEnvDuration("LEADER_ELECTION_LEASE_DURATION", 15*time.Second)
+	cfg.LeaderElectionRenewDeadline = parseEnvDuration("LEADER_ELECTION_RENEW_DEADLINE", 10*time.Second)
+	cfg.LeaderElectionRetryPeriod = parseEnvDuration("LEADER_ELECTION_RETRY_PERIOD", 2*time.Second)
+	cfg.ReadinessMaxAge = parseEnvDuration("READINESS_MAX_AGE", 0)
+	cfg.PrometheusRuleEnabled = parseEnvBool("PROMETHEUS_RULE_ENABLED", false)
+	cfg.PrometheusRuleNamespace = getEnvOrDefault("PROMETHEUS_RULE_NAMESPACE", "")
+	cfg.PrometheusRuleName = getEnvOrDefault("PROMETHEUS_RULE_NAME", "kubecertwatch")
+	cfg.PrometheusRuleLabels = parseEnvLabels("PROMETHEUS_RULE_LABELS")
+	cfg.ReportsEnabled = parseEnvBool("REPORTS_ENABLED", false)
+	cfg.ReportsMode = getEnvOrDefault("REPORTS_MODE", "object")
+	cfg.ReportsNamespace = getEnvOrDefault("REPORTS_NAMESPACE", "")
+	cfg.OTLPEndpoint = getEnvOrDefault("OTLP_ENDPOINT", "")
+	cfg.OTLPProtocol = getEnvOrDefault("OTLP_PROTOCOL", "grpc")
+	cfg.OTLPHeaders = parseEnvLabels("OTLP_HEADERS")
+	cfg.OTLPMetrics = parseEnvBool("OTLP_METRICS", true)
+	cfg.OTLPTraces = parseEnvBool("OTLP_TRACES", true)
+	cfg.OTLPExportInterval = parseEnvDuration("OTLP_EXPORT_INTERVAL", time.Minute)
+	return cfg
+}
+
+// Redacted returns a copy of c with its credentials replaced, for logging and printing.
+func (c AppConfig) Redacted() AppConfig {
+	redacted := c
+	if redacted.HubSharedSecret != "" {
+		redacted.HubSharedSecret = "REDACTED"
+	}
+	if redacted.Receivers != nil {
+		redacted.Receivers = make([]Receiver, len(c.Receivers))
+		for i, receiver := range c.Receivers {
+			if receiver.URL != "" {
+				receiver.URL = "REDACTED"
+			}
+			redacted.Receivers[i] = receiver
+		}
+	}
+	if redacted.OTLPHeaders != nil {
+		redacted.OTLPHeaders = make(map[string]string, len(c.OTLPHeaders))
+		for name := range c.OTLPHeaders {
+			redacted.OTLPHeaders[name] = "REDACTED"
+		}
+	}
+	return redacted
 }
 
 func getEnvOrDefault(key, defaultValue string) string {
@@ -82,6 +286,126 @@ func parseEnvBool(key string, defaultValue bool) bool {
 	}
 }
 
+func parseEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		log.Printf("Environment variable %s not set. Using default: %s", key, defaultValue)
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Error parsing %s as duration: %v. Using default value: %s", key, err, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// parseEnvList parses a comma-separated list, dropping empty entries.
+func parseEnvList(key string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		log.Printf("Environment variable %s not set. Using default: []", key)
+		return nil
+	}
+	return splitList(value)
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// parseEnvClusterKubeConfigs parses a comma-separated list of "name=/path/to/kubeconfig" entries.
+func parseEnvClusterKubeConfigs(key string) []ClusterKubeConfig {
+	return parseClusterKubeConfigs(parseEnvList(key))
+}
+
+// parseClusterKubeConfigs parses "name=/path/to/kubeconfig" entries.
+func parseClusterKubeConfigs(entries []string) []ClusterKubeConfig {
+	var clusters []ClusterKubeConfig
+	for _, entry := range entries {
+		name, path, _ := strings.Cut(entry, "=")
+		clusters = append(clusters, ClusterKubeConfig{Name: strings.TrimSpace(name), Path: strings.TrimSpace(path)})
+	}
+	return clusters
+}
+
+// parseEnvLabels parses a comma-separated list of name=value label pairs.
+func parseEnvLabels(key string) map[string]string {
+	return parseLabels(parseEnvList(key))
+}
+
+// parseLabels parses name=value label pairs.
+func parseLabels(entries []string) map[string]string {
+	if len(entries) == 0 {
+		return nil
+	}
+	labels := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		name, value, _ := strings.Cut(entry, "=")
+		labels[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return labels
+}
+
+// parseEnvTargets parses a comma-separated list of probe targets in the form
+// "host:port[;sni=name][;protocol=tls|https|smtp|...][;issuer=substring][;owner=team]".
+func parseEnvTargets(key string) []ProbeTarget {
+	value, exists := os.LookupEnv(key)
+	if !exists || strings.TrimSpace(value) == "" {
+		log.Printf("Environment variable %s not set. No targets configured.", key)
+		return nil
+	}
+	return parseTargets(value, key)
+}
+
+// parseTargets parses a comma-separated list of probe targets read from source.
+func parseTargets(value, source string) []ProbeTarget {
+	var targets []ProbeTarget
+	for _, entry := range splitList(value) {
+		fields := strings.Split(entry, ";")
+		target := ProbeTarget{Address: strings.TrimSpace(fields[0]), Protocol: "tls"}
+		for _, option := range fields[1:] {
+			name, optionValue, _ := strings.Cut(strings.TrimSpace(option), "=")
+			switch strings.ToLower(name) {
+			case "sni":
+				target.SNI = optionValue
+			case "protocol":
+				target.Protocol = strings.ToLower(optionValue)
+			case "issuer":
+				target.ExpectedIssuer = optionValue
+			case "owner":
+				target.Owner = optionValue
+			default:
+				log.Printf("Ignoring unknown option %q for target %s in %s", name, target.Address, source)
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// validateProbeTarget validates the address and protocol hint of a probe target
+func validateProbeTarget(target ProbeTarget) error {
+	host, port, err := net.SplitHostPort(target.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", target.Address, err)
+	}
+	if host == "" || port == "" {
+		return fmt.Errorf("invalid address %q: host and port are required", target.Address)
+	}
+	if IsProbeProtocol(target.Protocol) {
+		return nil
+	}
+	return fmt.Errorf("unsupported protocol %q for %s (supported: %s)", target.Protocol, target.Address, strings.Join(probeProtocols, ", "))
+}
+
 // validateCronExpression validates the cron expression format
 func validateCronExpression(cronExpr string) error {
 	if cronExpr == "" {
@@ -97,21 +421,112 @@ func validateCronExpression(cronExpr string) error {
 	return nil
 }
 
-// ValidateRequiredConfig validates required configuration.
+// ValidateRequiredConfig validates the active configuration.
 func ValidateRequiredConfig() error {
-	if CFG.ClusterName == "" {
-		return fmt.Errorf("CLUSTER_NAME is required but not set")
+	return Current().Validate()
+}
+
+// Validate checks every setting of c and reports all problems found at once.
+func (c AppConfig) Validate() error {
+	var errs []error
+	if c.ClusterName == "" {
+		errs = append(errs, fmt.Errorf("CLUSTER_NAME is required but not set"))
 	}
 
 	// Validate cron expression
-	if err := validateCronExpression(CFG.CronSchedule); err != nil {
-		return fmt.Errorf("CRON_SCHEDULE validation failed: %v", err)
+	if err := validateCronExpression(c.CronSchedule); err != nil {
+		errs = append(errs, fmt.Errorf("CRON_SCHEDULE validation failed: %v", err))
 	}
 
 	// Validate metrics port
-	if CFG.MetricsPort < 1024 || CFG.MetricsPort > 65535 {
-		return fmt.Errorf("METRICS_PORT must be between 1024 and 65535, got %d", CFG.MetricsPort)
+	if c.MetricsPort < 1024 || c.MetricsPort > 65535 {
+		errs = append(errs, fmt.Errorf("METRICS_PORT must be between 1024 and 65535, got %d", c.MetricsPort))
 	}
 
-	return nil
+	if c.ExpiryWarningDays < 1 {
+		errs = append(errs, fmt.Errorf("EXPIRY_WARNING_DAYS must be at least 1, got %d", c.ExpiryWarningDays))
+	}
+
+	// Validate metric naming
+	if c.MetricsPrefix != "" && !metricNamePattern.MatchString(c.MetricsPrefix) {
+		errs = append(errs, fmt.Errorf("METRICS_PREFIX %q is not a valid Prometheus metric name prefix", c.MetricsPrefix))
+	}
+	for name := range c.MetricsConstLabels {
+		if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+			errs = append(errs, fmt.Errorf("METRICS_CONST_LABELS contains invalid label name %q", name))
+		}
+		if name == "cluster" {
+			errs = append(errs, fmt.Errorf("METRICS_CONST_LABELS must not set the cluster label; use CLUSTER_NAME"))
+		}
+	}
+
+	if c.PrometheusRuleEnabled && c.PrometheusRuleName == "" {
+		errs = append(errs, fmt.Errorf("PROMETHEUS_RULE_NAME must not be empty when PROMETHEUS_RULE_ENABLED is set"))
+	}
+
+	if c.ReportsEnabled && c.ReportsMode != "object" && c.ReportsMode != "namespace" {
+		errs = append(errs, fmt.Errorf("REPORTS_MODE must be object or namespace, got %q", c.ReportsMode))
+	}
+
+	// Validate OpenTelemetry export
+	if c.OTLPEndpoint != "" {
+		endpoint, err := url.Parse(c.OTLPEndpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			errs = append(errs, fmt.Errorf("OTLP_ENDPOINT must be an http:// or https:// URL, got %q", c.OTLPEndpoint))
+		}
+		if c.OTLPProtocol != "grpc" && c.OTLPProtocol != "http" {
+			errs = append(errs, fmt.Errorf("OTLP_PROTOCOL must be grpc or http, got %q", c.OTLPProtocol))
+		}
+		if c.OTLPMetrics && c.OTLPExportInterval <= 0 {
+			errs = append(errs, fmt.Errorf("OTLP_EXPORT_INTERVAL must be positive, got %s", c.OTLPExportInterval))
+		}
+	}
+
+	// Validate remote cluster kubeconfigs
+	for _, cluster := range c.ClusterKubeConfigs {
+		if cluster.Name == "" || cluster.Path == "" {
+			errs = append(errs, fmt.Errorf("CLUSTER_KUBECONFIGS entries must have the form name=path, got %q=%q", cluster.Name, cluster.Path))
+		}
+	}
+
+	// Validate agent and hub settings
+	if c.HubURL != "" && c.HubSharedSecret == "" {
+		errs = append(errs, fmt.Errorf("HUB_SHARED_SECRET is required when HUB_URL is set"))
+	}
+	if c.HubEnabled && c.HubAgentKeysDir == "" {
+		errs = append(errs, fmt.Errorf("HUB_AGENT_KEYS_DIR is required when HUB_ENABLED is set"))
+	}
+	if c.HubURL != "" && !strings.HasPrefix(c.HubURL, "http://") && !strings.HasPrefix(c.HubURL, "https://") {
+		errs = append(errs, fmt.Errorf("HUB_URL must be an http:// or https:// URL, got %q", c.HubURL))
+	}
+	if c.HubEnabled && c.HubStaleAfter <= 0 {
+		errs = append(errs, fmt.Errorf("HUB_STALE_AFTER must be positive, got %s", c.HubStaleAfter))
+	}
+
+	// Validate leader election timings
+	if c.LeaderElection {
+		if c.LeaderElectionLeaseName == "" {
+			errs = append(errs, fmt.Errorf("LEADER_ELECTION_LEASE_NAME must not be empty"))
+		}
+		if c.LeaderElectionRetryPeriod <= 0 || c.LeaderElectionRenewDeadline <= c.LeaderElectionRetryPeriod ||
+			c.LeaderElectionLeaseDuration <= c.LeaderElectionRenewDeadline {
+			errs = append(errs, fmt.Errorf("leader election requires LEASE_DURATION > RENEW_DEADLINE > RETRY_PERIOD > 0, got %s, %s, %s",
+				c.LeaderElectionLeaseDuration, c.LeaderElectionRenewDeadline, c.LeaderElectionRetryPeriod))
+		}
+	}
+
+	if c.ReadinessMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("READINESS_MAX_AGE must not be negative, got %s", c.ReadinessMaxAge))
+	}
+
+	errs = append(errs, validateNotifications(c.Receivers, c.Route)...)
+
+	// Validate external probe targets
+	for _, target := range c.ExternalTargets {
+		if err := validateProbeTarget(target); err != nil {
+			errs = append(errs, fmt.Errorf("EXTERNAL_TARGETS validation failed: %v", err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,123 @@
+// pkg/config/file.go
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Schema version and kind of the config file.
+const (
+	FileAPIVersion = "kubecertwatch.io/v1alpha1"
+	FileKind       = "Config"
+)
+
+// loadFile overrides the settings of cfg that are present in the YAML or JSON config file at path.
+// Keys are the JSON names of the AppConfig fields and durations are strings such as "15s".
+func loadFile(path string, cfg *AppConfig) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal(jsonData, &settings); err != nil {
+		return err
+	}
+	if settings["apiVersion"] != FileAPIVersion || settings["kind"] != FileKind {
+		return fmt.Errorf("expected apiVersion %q and kind %q, got %v and %v", FileAPIVersion, FileKind, settings["apiVersion"], settings["kind"])
+	}
+	delete(settings, "apiVersion")
+	delete(settings, "kind")
+
+	fields := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < fields.NumField(); i++ {
+		name := jsonName(fields.Type().Field(i))
+		value, ok := settings[name]
+		if !ok {
+			continue
+		}
+		// Replace rather than merge lists and maps set in an earlier layer
+		fields.Field(i).Set(reflect.Zero(fields.Field(i).Type()))
+
+		if fields.Field(i).Type() == reflect.TypeOf(time.Duration(0)) {
+			text, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s must be a duration string such as \"30s\", got %v", name, value)
+			}
+			duration, err := time.ParseDuration(text)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			settings[name] = int64(duration)
+		}
+	}
+
+	normalized, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(normalized))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return err
+	}
+
+	for i := range cfg.ExternalTargets {
+		cfg.ExternalTargets[i].Protocol = strings.ToLower(cfg.ExternalTargets[i].Protocol)
+		if cfg.ExternalTargets[i].Protocol == "" {
+			cfg.ExternalTargets[i].Protocol = "tls"
+		}
+	}
+	return nil
+}
+
+// PrintConfig writes the effective configuration, with credentials redacted, to w in the config file format.
+func PrintConfig(w io.Writer) error {
+	data, err := json.Marshal(Current().Redacted())
+	if err != nil {
+		return err
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+
+	fields := reflect.TypeOf(AppConfig{})
+	for i := 0; i < fields.NumField(); i++ {
+		if fields.Field(i).Type != reflect.TypeOf(time.Duration(0)) {
+			continue
+		}
+		name := jsonName(fields.Field(i))
+		if value, ok := settings[name].(float64); ok {
+			settings[name] = time.Duration(value).String()
+		}
+	}
+	settings["apiVersion"] = FileAPIVersion
+	settings["kind"] = FileKind
+
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// jsonName returns the key of field in the config file.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,132 @@
+// pkg/config/flags.go
+package config
+
+import (
+	"flag"
+	"os"
+	"sort"
+	"strings"
+)
+
+// newFlagSet returns the command-line flags, which are named after the environment variables they
+// override and write directly into cfg. Credentials have no flags, to keep them out of process listings.
+func newFlagSet(cfg *AppConfig, opts *Options) *flag.FlagSet {
+	fs := flag.NewFlagSet("kubecertwatch", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	fs.StringVar(&opts.ConfigFile, "config", opts.ConfigFile, "YAML or JSON config file (env CONFIG_FILE)")
+	fs.BoolVar(&opts.PrintConfig, "print-config", false, "Print the effective configuration with credentials redacted and exit")
+
+	fs.BoolVar(&cfg.Debug, "debug", cfg.Debug, "Enable debug logging")
+	fs.IntVar(&cfg.MetricsPort, "metrics-port", cfg.MetricsPort, "Port of the metrics and status server")
+	fs.StringVar(&cfg.MetricsPrefix, "metrics-prefix", cfg.MetricsPrefix, "Prefix of every metric name")
+	fs.Var(labelsValue{&cfg.MetricsConstLabels}, "metrics-const-labels", "Comma-separated name=value labels added to every series")
+	fs.StringVar(&cfg.CronSchedule, "cron-schedule", cfg.CronSchedule, "Check schedule in cron format")
+	fs.IntVar(&cfg.ExpiryWarningDays, "expiry-warning-days", cfg.ExpiryWarningDays, "Days before expiration at which certificates are expiring soon")
+	fs.StringVar(&cfg.ClusterName, "cluster-name", cfg.ClusterName, "Name of the local cluster")
+	fs.StringVar(&cfg.KubeConfig, "kubeconfig", cfg.KubeConfig, "Kubeconfig file used instead of the in-cluster config")
+	fs.BoolVar(&cfg.WebhookProbeServices, "webhook-probe-services", cfg.WebhookProbeServices, "Verify webhook serving certificates against their caBundle")
+	fs.Var(targetsValue{&cfg.ExternalTargets}, "external-targets", "Comma-separated external endpoints to probe")
+	fs.BoolVar(&cfg.ProbeLegacyTLS, "probe-legacy-tls", cfg.ProbeLegacyTLS, "Test whether probed endpoints accept TLS 1.0 and 1.1")
+	fs.BoolVar(&cfg.RevocationCheck, "revocation-check", cfg.RevocationCheck, "Check OCSP and CRL revocation")
+	fs.Var(listValue{&cfg.KubeConfigContexts}, "kubeconfig-contexts", "Comma-separated kubeconfig contexts to monitor")
+	fs.Var(kubeConfigsValue{&cfg.ClusterKubeConfigs}, "cluster-kubeconfigs", "Comma-separated name=/path/to/kubeconfig remote clusters")
+	fs.StringVar(&cfg.ClusterSecretNamespace, "cluster-secret-namespace", cfg.ClusterSecretNamespace, "Namespace searched for remote cluster Secrets")
+	fs.StringVar(&cfg.HubURL, "hub-url", cfg.HubURL, "Agent mode: push snapshots to this hub")
+	fs.BoolVar(&cfg.HubEnabled, "hub-enabled", cfg.HubEnabled, "Hub mode: accept snapshots from agents")
+	fs.StringVar(&cfg.HubAgentKeysDir, "hub-agent-keys-dir", cfg.HubAgentKeysDir, "Hub mode: directory holding one key file per agent, named after the agent")
+	fs.DurationVar(&cfg.HubStaleAfter, "hub-stale-after", cfg.HubStaleAfter, "Flag agents that have not reported for this long")
+	fs.BoolVar(&cfg.LeaderElection, "leader-election", cfg.LeaderElection, "Only run scheduled checks while holding the Lease")
+	fs.StringVar(&cfg.LeaderElectionNamespace, "leader-election-namespace", cfg.LeaderElectionNamespace, "Namespace of the Lease")
+	fs.StringVar(&cfg.LeaderElectionLeaseName, "leader-election-lease-name", cfg.LeaderElectionLeaseName, "Name of the Lease")
+	fs.DurationVar(&cfg.LeaderElectionLeaseDuration, "leader-election-lease-duration", cfg.LeaderElectionLeaseDuration, "Lease duration")
+	fs.DurationVar(&cfg.LeaderElectionRenewDeadline, "leader-election-renew-deadline", cfg.LeaderElectionRenewDeadline, "Lease renew deadline")
+	fs.DurationVar(&cfg.LeaderElectionRetryPeriod, "leader-election-retry-period", cfg.LeaderElectionRetryPeriod, "Lease retry period")
+	fs.DurationVar(&cfg.ReadinessMaxAge, "readiness-max-age", cfg.ReadinessMaxAge, "Maximum age of the last successful run before /readyz fails")
+	fs.BoolVar(&cfg.PrometheusRuleEnabled, "prometheus-rule-enabled", cfg.PrometheusRuleEnabled, "Create or update a PrometheusRule")
+	fs.StringVar(&cfg.PrometheusRuleNamespace, "prometheus-rule-namespace", cfg.PrometheusRuleNamespace, "Namespace of the PrometheusRule")
+	fs.StringVar(&cfg.PrometheusRuleName, "prometheus-rule-name", cfg.PrometheusRuleName, "Name of the PrometheusRule")
+	fs.Var(labelsValue{&cfg.PrometheusRuleLabels}, "prometheus-rule-labels", "Comma-separated name=value labels of the PrometheusRule")
+	fs.BoolVar(&cfg.ReportsEnabled, "reports-enabled", cfg.ReportsEnabled, "Write the results as CertificateReport resources")
+	fs.StringVar(&cfg.ReportsMode, "reports-mode", cfg.ReportsMode, "One CertificateReport per object or per namespace")
+	fs.StringVar(&cfg.ReportsNamespace, "reports-namespace", cfg.ReportsNamespace, "Namespace of the reports of cluster-scoped objects and external endpoints")
+	fs.StringVar(&cfg.OTLPEndpoint, "otlp-endpoint", cfg.OTLPEndpoint, "URL of an OTLP collector")
+	fs.StringVar(&cfg.OTLPProtocol, "otlp-protocol", cfg.OTLPProtocol, "OTLP transport, grpc or http")
+	fs.BoolVar(&cfg.OTLPMetrics, "otlp-metrics", cfg.OTLPMetrics, "Export metrics over OTLP")
+	fs.BoolVar(&cfg.OTLPTraces, "otlp-traces", cfg.OTLPTraces, "Export check run traces over OTLP")
+	fs.DurationVar(&cfg.OTLPExportInterval, "otlp-export-interval", cfg.OTLPExportInterval, "Interval between OTLP metric exports")
+	return fs
+}
+
+// listValue is a flag holding a comma-separated list.
+type listValue struct{ list *[]string }
+
+func (v listValue) String() string {
+	if v.list == nil {
+		return ""
+	}
+	return strings.Join(*v.list, ",")
+}
+
+func (v listValue) Set(value string) error {
+	*v.list = splitList(value)
+	return nil
+}
+
+// labelsValue is a flag holding comma-separated name=value pairs.
+type labelsValue struct{ labels *map[string]string }
+
+func (v labelsValue) String() string {
+	if v.labels == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*v.labels))
+	for name, value := range *v.labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v labelsValue) Set(value string) error {
+	*v.labels = parseLabels(splitList(value))
+	return nil
+}
+
+// targetsValue is a flag holding probe targets in the EXTERNAL_TARGETS format.
+type targetsValue struct{ targets *[]ProbeTarget }
+
+func (v targetsValue) String() string {
+	if v.targets == nil {
+		return ""
+	}
+	entries := make([]string, 0, len(*v.targets))
+	for _, target := range *v.targets {
+		entries = append(entries, target.Address)
+	}
+	return strings.Join(entries, ",")
+}
+
+func (v targetsValue) Set(value string) error {
+	*v.targets = parseTargets(value, "--external-targets")
+	return nil
+}
+
+// kubeConfigsValue is a flag holding name=/path/to/kubeconfig remote clusters.
+type kubeConfigsValue struct{ clusters *[]ClusterKubeConfig }
+
+func (v kubeConfigsValue) String() string {
+	if v.clusters == nil {
+		return ""
+	}
+	entries := make([]string, 0, len(*v.clusters))
+	for _, cluster := range *v.clusters {
+		entries = append(entries, cluster.Name+"="+cluster.Path)
+	}
+	return strings.Join(entries, ",")
+}
+
+func (v kubeConfigsValue) Set(value string) error {
+	*v.clusters = parseClusterKubeConfigs(splitList(value))
+	return nil
+}
@@ -0,0 +1,128 @@
+// pkg/config/notify.go
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Receiver types.
+const (
+	ReceiverWebhook = "webhook"
+	ReceiverSlack   = "slack"
+)
+
+// RouteLabels are the finding labels that routes can match on.
+var RouteLabels = []string{"cluster", "namespace", "owner", "kind", "severity"}
+
+// Receiver is a destination of notifications. Its URL is a credential for most services, so it can be
+// read from a mounted Secret through URLFile instead.
+type Receiver struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	URL     string `json:"url,omitempty"`
+	URLFile string `json:"urlFile,omitempty"`
+}
+
+// Route is a node of the notification routing tree. Like an Alertmanager route, a finding descends into
+// the first child route that matches it, or into every matching child up to the first one without
+// Continue, and is sent to the receiver of the deepest matching routes. Routes without a receiver or
+// quiet windows inherit those of their parent.
+type Route struct {
+	Receiver     string            `json:"receiver,omitempty"`
+	Match        map[string]string `json:"match,omitempty"`
+	MatchRE      map[string]string `json:"matchRE,omitempty"`
+	Continue     bool              `json:"continue,omitempty"`
+	QuietWindows []QuietWindow     `json:"quietWindows,omitempty"`
+	Routes       []Route           `json:"routes,omitempty"`
+}
+
+// validateNotifications checks the receivers and the routing tree.
+func validateNotifications(receivers []Receiver, route *Route) []error {
+	var errs []error
+	names := map[string]bool{}
+	for _, receiver := range receivers {
+		if receiver.Name == "" {
+			errs = append(errs, fmt.Errorf("receivers must have a name"))
+			continue
+		}
+		if names[receiver.Name] {
+			errs = append(errs, fmt.Errorf("receiver %q is defined more than once", receiver.Name))
+		}
+		names[receiver.Name] = true
+
+		if receiver.Type != ReceiverWebhook && receiver.Type != ReceiverSlack {
+			errs = append(errs, fmt.Errorf("receiver %q: type must be %s or %s, got %q", receiver.Name, ReceiverWebhook, ReceiverSlack, receiver.Type))
+		}
+		switch {
+		case (receiver.URL == "") == (receiver.URLFile == ""):
+			errs = append(errs, fmt.Errorf("receiver %q: exactly one of url and urlFile must be set", receiver.Name))
+		case receiver.URL != "":
+			if err := ValidateReceiverURL(receiver.URL); err != nil {
+				errs = append(errs, fmt.Errorf("receiver %q: %v", receiver.Name, err))
+			}
+		}
+	}
+
+	if route == nil {
+		if len(receivers) > 0 {
+			errs = append(errs, fmt.Errorf("route is required when receivers are defined"))
+		}
+		return errs
+	}
+	if route.Receiver == "" {
+		errs = append(errs, fmt.Errorf("the root route must have a receiver"))
+	}
+	return append(errs, validateRoute(*route, names, "route")...)
+}
+
+// validateRoute checks route and its children. path locates the route in error messages.
+func validateRoute(route Route, receivers map[string]bool, path string) []error {
+	var errs []error
+	if route.Receiver != "" && !receivers[route.Receiver] {
+		errs = append(errs, fmt.Errorf("%s: unknown receiver %q", path, route.Receiver))
+	}
+	for name := range route.Match {
+		if !isRouteLabel(name) {
+			errs = append(errs, fmt.Errorf("%s: cannot match on %q (supported: %s)", path, name, strings.Join(RouteLabels, ", ")))
+		}
+	}
+	for name, pattern := range route.MatchRE {
+		if !isRouteLabel(name) {
+			errs = append(errs, fmt.Errorf("%s: cannot match on %q (supported: %s)", path, name, strings.Join(RouteLabels, ", ")))
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid matchRE for %s: %v", path, name, err))
+		}
+	}
+	for i, window := range route.QuietWindows {
+		if err := window.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s.quietWindows[%d]: %v", path, i, err))
+		}
+	}
+	for i, child := range route.Routes {
+		errs = append(errs, validateRoute(child, receivers, fmt.Sprintf("%s.routes[%d]", path, i))...)
+	}
+	return errs
+}
+
+// isRouteLabel reports whether routes can match on the finding label name.
+func isRouteLabel(name string) bool {
+	for _, label := range RouteLabels {
+		if name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateReceiverURL checks that rawURL is an http:// or https:// URL.
+func ValidateReceiverURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an http:// or https:// URL")
+	}
+	return nil
+}
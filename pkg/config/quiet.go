@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// Timezones are resolved without relying on the zoneinfo files of the image
+	_ "time/tzdata"
+)
+
+// maxChainedWindows bounds how many back-to-back quiet windows QuietUntil follows.
+const maxChainedWindows = 64
+
+// QuietWindow is a period in which the non-critical notifications of a route are held until the window
+// ends. A recurring window opens at Start and closes at End on each of Days, or on every day when Days
+// is empty; a window whose End is not after its Start, such as 22:00 to 07:00, closes the next day, and
+// one without Start and End spans whole days. A dated window, such as a change freeze, spans From to
+// Until. Times are read in Timezone, UTC by default.
+type QuietWindow struct {
+	Days     []string `json:"days,omitempty"`
+	Start    string   `json:"start,omitempty"`
+	End      string   `json:"end,omitempty"`
+	From     string   `json:"from,omitempty"`
+	Until    string   `json:"until,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// dateLayouts are the accepted formats of From and Until; those without an offset are read in the
+// window's timezone.
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02"}
+
+// Validate checks the window.
+func (w QuietWindow) Validate() error {
+	_, err := w.parse()
+	return err
+}
+
+// closesAt returns when the window that is open at now closes, or false when it is not open at now.
+func (w QuietWindow) closesAt(now time.Time) (time.Time, bool) {
+	parsed, err := w.parse()
+	if err != nil {
+		return time.Time{}, false
+	}
+	if !parsed.from.IsZero() {
+		return parsed.until, !now.Before(parsed.from) && now.Before(parsed.until)
+	}
+
+	local := now.In(parsed.location)
+	// The window open at now started today or, when it runs past midnight, yesterday
+	for offset := -1; offset <= 0; offset++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, parsed.location)
+		if len(parsed.days) > 0 && !parsed.days[day.Weekday()] {
+			continue
+		}
+		opens := clockOn(day, 0, parsed.start)
+		closes := clockOn(day, 0, parsed.end)
+		if parsed.end <= parsed.start {
+			closes = clockOn(day, 1, parsed.end)
+		}
+		if !now.Before(opens) && now.Before(closes) {
+			return closes, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// clockOn returns the time of day clock on the day offset days after day, in the location of day.
+func clockOn(day time.Time, offset int, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day()+offset, 0, 0, int(clock.Seconds()), 0, day.Location())
+}
+
+// QuietUntil returns when the quiet period of windows that is in effect at now ends, following windows
+// that open as soon as another one closes, or false when none of windows is open at now.
+func QuietUntil(windows []QuietWindow, now time.Time) (time.Time, bool) {
+	until, quiet := now, false
+	for i := 0; i < maxChainedWindows; i++ {
+		extended := false
+		for _, window := range windows {
+			if closes, open := window.closesAt(until); open && closes.After(until) {
+				until, quiet, extended = closes, true, true
+			}
+		}
+		if !extended {
+			break
+		}
+	}
+	return until, quiet
+}
+
+// parsedWindow is a QuietWindow with its times parsed.
+type parsedWindow struct {
+	location    *time.Location
+	days        map[time.Weekday]bool
+	start, end  time.Duration
+	from, until time.Time
+}
+
+// parse reads the times of the window.
+func (w QuietWindow) parse() (parsedWindow, error) {
+	parsed := parsedWindow{location: time.UTC}
+	if w.Timezone != "" {
+		location, err := time.LoadLocation(w.Timezone)
+		if err != nil {
+			return parsed, fmt.Errorf("unknown timezone %q", w.Timezone)
+		}
+		parsed.location = location
+	}
+
+	if w.From != "" || w.Until != "" {
+		if w.Start != "" || w.End != "" || len(w.Days) > 0 {
+			return parsed, fmt.Errorf("from and until cannot be combined with days, start and end")
+		}
+		var err error
+		if parsed.from, err = parseDate(w.From, parsed.location); err != nil {
+			return parsed, fmt.Errorf("from: %v", err)
+		}
+		if parsed.until, err = parseDate(w.Until, parsed.location); err != nil {
+			return parsed, fmt.Errorf("until: %v", err)
+		}
+		if !parsed.until.After(parsed.from) {
+			return parsed, fmt.Errorf("until must be after from")
+		}
+		return parsed, nil
+	}
+
+	if len(w.Days) > 0 {
+		parsed.days = map[time.Weekday]bool{}
+		for _, name := range w.Days {
+			day, ok := parseWeekday(name)
+			if !ok {
+				return parsed, fmt.Errorf("unknown day %q (expected mon, tue, wed, thu, fri, sat or sun)", name)
+			}
+			parsed.days[day] = true
+		}
+	}
+	if w.Start == "" && w.End == "" {
+		if len(w.Days) == 0 {
+			return parsed, fmt.Errorf("a quiet window needs days, start and end, or from and until")
+		}
+		return parsed, nil
+	}
+	var err error
+	if parsed.start, err = parseClock(w.Start); err != nil {
+		return parsed, fmt.Errorf("start: %v", err)
+	}
+	if parsed.end, err = parseClock(w.End); err != nil {
+		return parsed, fmt.Errorf("end: %v", err)
+	}
+	return parsed, nil
+}
+
+// parseDate reads a From or Until value.
+func parseDate(value string, location *time.Location) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected a date such as 2025-12-20, 2025-12-20T18:00 or an RFC 3339 time", value)
+}
+
+// parseClock reads a time of day such as 22:00 as the offset from midnight.
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q: expected a time such as 22:00", value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// parseWeekday reads a day such as mon or Monday.
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || name == full[:3] {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,102 @@
+// pkg/config/reload.go
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// fileCheckInterval is how often WatchFile looks for changes to the config file.
+const fileCheckInterval = 10 * time.Second
+
+// reloadLock serializes reloads triggered by SIGHUP and by the config file watcher.
+var reloadLock sync.Mutex
+
+// startupSettings are the fields that are only read at startup. Reloads keep their current values.
+var startupSettings = []string{
+	"MetricsPort", "MetricsPrefix", "MetricsConstLabels", "ClusterName", "KubeConfig", "HubEnabled",
+	"LeaderElection", "LeaderElectionNamespace", "LeaderElectionLeaseName",
+	"LeaderElectionLeaseDuration", "LeaderElectionRenewDeadline", "LeaderElectionRetryPeriod",
+	"OTLPEndpoint", "OTLPProtocol", "OTLPHeaders", "OTLPMetrics", "OTLPTraces", "OTLPExportInterval",
+}
+
+// Reload reads every configuration layer again and activates the result if it is valid. Otherwise the
+// active configuration is kept and the validation error is returned. Settings that are only read at
+// startup keep their values; the names of those that changed are returned so that callers can warn.
+func Reload() ([]string, error) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	cfg, _, err := load(loadedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := Current()
+	active := reflect.ValueOf(previous).Elem()
+	reloaded := reflect.ValueOf(&cfg).Elem()
+	var pinned []string
+	for _, name := range startupSettings {
+		if !reflect.DeepEqual(active.FieldByName(name).Interface(), reloaded.FieldByName(name).Interface()) {
+			pinned = append(pinned, name)
+			reloaded.FieldByName(name).Set(active.FieldByName(name))
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	current.Store(&cfg)
+
+	if cfg.Debug {
+		log.Printf("Configuration Reloaded: %+v\n", cfg.Redacted())
+	}
+	return pinned, nil
+}
+
+// WatchFile calls onChange whenever the content of the config file changes, until ctx is done. It polls
+// rather than relying on file events, because ConfigMap volumes are updated by swapping a symlink.
+func WatchFile(ctx context.Context, onChange func()) {
+	path := loadedOptions.ConfigFile
+	if path == "" {
+		return
+	}
+
+	last, err := fileDigest(path)
+	if err != nil {
+		log.Printf("Failed to read config file %s: %v", path, err)
+	}
+	ticker := time.NewTicker(fileCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		digest, err := fileDigest(path)
+		if err != nil {
+			log.Printf("Failed to read config file %s: %v", path, err)
+			continue
+		}
+		if digest != last {
+			last = digest
+			onChange()
+		}
+	}
+}
+
+// fileDigest returns the SHA-256 digest of the file at path.
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
@@ -0,0 +1,642 @@
+// pkg/coordinator/coordinator.go
+package coordinator
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/robfig/cron/v3"
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/hub"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/leader"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/notify"
+	"github.com/supporttools/KubeCertWatch/pkg/reports"
+	"github.com/supporttools/KubeCertWatch/pkg/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Triggers that start a run.
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// States of a run.
+const (
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+)
+
+const (
+	// runTimeout bounds the duration of a single run.
+	runTimeout = 5 * time.Minute
+	// runHistory is the number of runs kept for the runs API.
+	runHistory = 100
+)
+
+var (
+	log = logging.SetupLogging()
+
+	// ErrBusy is returned when a requested check is already part of another run.
+	ErrBusy = errors.New("check already running")
+	// ErrUnknownCheck is returned when a requested check does not exist.
+	ErrUnknownCheck = errors.New("unknown check")
+
+	runLock     sync.Mutex
+	running     = map[string]string{} // check name -> ID of the run executing it
+	runs        []*Run                // most recent runs, oldest first
+	checkStates = map[string]*CheckState{}
+
+	timedLock     sync.Mutex
+	timedClusters = map[string]bool{} // clusters with last_check_time series
+)
+
+// Check is a certificate check executed by the coordinator.
+type Check struct {
+	// Name identifies the check in /check/<name> and in runs.
+	Name string
+	// Description is used in log and HTTP messages.
+	Description string
+	// ErrorType and TimeName are the labels of certificate_check_errors_total and last_check_time.
+	ErrorType string
+	TimeName  string
+	// Scheduled checks are part of every scheduled run.
+	Scheduled bool
+	// PerCluster checks run once for every monitored cluster and are retried on failure;
+	// the others run once on behalf of the local cluster.
+	PerCluster bool
+	Run        func(ctx context.Context, cluster k8s.Cluster, results *checks.Results) error
+}
+
+// registry lists every check in the order they are reported.
+var registry = []Check{
+	{Name: "secrets", Description: "TLS secrets check", ErrorType: "tls-secrets", TimeName: "tls-secret-check", Scheduled: true, PerCluster: true, Run: checks.CheckTLSSecrets},
+	{Name: "cert-manager", Description: "cert-manager check", ErrorType: "cert-manager", TimeName: "cert-manager-check", Scheduled: true, PerCluster: true, Run: checks.CheckCertManagerCertificates},
+	{Name: "ingress", Description: "Ingress check", ErrorType: "ingress", TimeName: "ingress-check", PerCluster: true, Run: checks.CheckIngress},
+	{Name: "webhooks", Description: "Webhook caBundle check", ErrorType: "webhooks", TimeName: "webhook-check", Scheduled: true, PerCluster: true, Run: checks.CheckWebhookCABundles},
+	{Name: "gateways", Description: "Gateway check", ErrorType: "gateways", TimeName: "gateway-check", Scheduled: true, PerCluster: true, Run: checks.CheckGateways},
+	{Name: "routes", Description: "Route check", ErrorType: "routes", TimeName: "route-check", Scheduled: true, PerCluster: true, Run: checks.CheckRoutes},
+	{Name: "services", Description: "Service TLS probe", ErrorType: "services", TimeName: "service-probe", Scheduled: true, PerCluster: true, Run: checks.CheckServices},
+	{Name: "external", Description: "External endpoint probe", ErrorType: "external", TimeName: "external-probe", Scheduled: true, Run: runExternal},
+}
+
+// Run is a single execution of one or more checks.
+type Run struct {
+	ID        string
+	Trigger   string
+	Checks    []string
+	Skipped   []string
+	StartTime time.Time
+
+	mu       sync.Mutex
+	clusters []string
+	endTime  time.Time
+	errors   []string
+	results  []CheckResult
+	done     chan struct{}
+}
+
+// CheckResult is the outcome of one check in one cluster.
+type CheckResult struct {
+	Check     string    `json:"check"`
+	Cluster   string    `json:"cluster"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Objects   int       `json:"objects"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// RunStatus is a point-in-time view of a run, as returned by the runs API.
+type RunStatus struct {
+	ID              string        `json:"id"`
+	Trigger         string        `json:"trigger"`
+	State           string        `json:"state"`
+	Checks          []string      `json:"checks"`
+	Skipped         []string      `json:"skipped,omitempty"`
+	Clusters        []string      `json:"clusters"`
+	StartTime       time.Time     `json:"startTime"`
+	EndTime         *time.Time    `json:"endTime,omitempty"`
+	DurationSeconds float64       `json:"durationSeconds"`
+	Errors          []string      `json:"errors,omitempty"`
+	Results         []CheckResult `json:"results"`
+}
+
+// CheckState is the outcome of the last run of a check.
+type CheckState struct {
+	Check            string     `json:"check"`
+	Scheduled        bool       `json:"scheduled"`
+	Running          bool       `json:"running"`
+	LastRun          string     `json:"lastRun,omitempty"`
+	LastRunTime      *time.Time `json:"lastRunTime,omitempty"`
+	LastSuccess      *time.Time `json:"lastSuccess,omitempty"`
+	LastLocalSuccess *time.Time `json:"lastLocalSuccess,omitempty"`
+	LastError        string     `json:"lastError,omitempty"`
+}
+
+// Done is closed when the run has completed and its results have been published.
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// Status returns the current state of the run.
+func (r *Run) Status() RunStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := RunStatus{
+		ID:        r.ID,
+		Trigger:   r.Trigger,
+		State:     StateRunning,
+		Checks:    append([]string(nil), r.Checks...),
+		Skipped:   append([]string(nil), r.Skipped...),
+		Clusters:  append([]string{}, r.clusters...),
+		StartTime: r.StartTime,
+		Errors:    append([]string(nil), r.errors...),
+		Results:   append([]CheckResult{}, r.results...),
+	}
+	end := time.Now()
+	if !r.endTime.IsZero() {
+		end = r.endTime
+		status.EndTime = &end
+		status.State = StateSucceeded
+		if len(r.errors) > 0 {
+			status.State = StateFailed
+		}
+	}
+	status.DurationSeconds = end.Sub(r.StartTime).Seconds()
+	return status
+}
+
+// addError records a failure of the run.
+func (r *Run) addError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, err.Error())
+}
+
+// addResult records the outcome of a check, counting its failure as an error of the run.
+func (r *Run) addResult(result CheckResult) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.results = append(r.results, result)
+	if result.Error != "" {
+		r.errors = append(r.errors, fmt.Sprintf("%s: %s: %s", result.Cluster, result.Check, result.Error))
+	}
+}
+
+// clusterNames returns the clusters the run covers.
+func (r *Run) clusterNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.clusters...)
+}
+
+// Checks returns the registered checks.
+func Checks() []Check {
+	return append([]Check(nil), registry...)
+}
+
+// ScheduledChecks returns the names of the checks that are part of every scheduled run.
+func ScheduledChecks() []string {
+	var names []string
+	for _, check := range registry {
+		if check.Scheduled {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// GetCheckStates returns the outcome of the last run of every check, in registry order.
+func GetCheckStates() []CheckState {
+	runLock.Lock()
+	defer runLock.Unlock()
+
+	states := make([]CheckState, 0, len(registry))
+	for _, check := range registry {
+		state := CheckState{Check: check.Name, Scheduled: check.Scheduled}
+		if last := checkStates[check.Name]; last != nil {
+			state = *last
+		}
+		_, state.Running = running[check.Name]
+		states = append(states, state)
+	}
+	return states
+}
+
+// StalledRun returns the oldest run that has been running for more than twice the run timeout, which
+// means that one of its checks ignores cancellation.
+func StalledRun() (*Run, bool) {
+	runLock.Lock()
+	defer runLock.Unlock()
+
+	owners := map[string]bool{}
+	for _, id := range running {
+		owners[id] = true
+	}
+	for _, run := range runs {
+		if owners[run.ID] && time.Since(run.StartTime) > 2*runTimeout {
+			return run, true
+		}
+	}
+	return nil, false
+}
+
+// MaxRunAge returns how long a scheduled check may go without a successful run before it is considered
+// stale: READINESS_MAX_AGE, defaulting to two intervals of CRON_SCHEDULE so that a single failed run
+// is tolerated.
+func MaxRunAge() time.Duration {
+	if config.Current().ReadinessMaxAge > 0 {
+		return config.Current().ReadinessMaxAge
+	}
+	schedule, err := cron.ParseStandard(config.Current().CronSchedule)
+	if err != nil {
+		return 24 * time.Hour
+	}
+	next := schedule.Next(time.Now())
+	return 2 * schedule.Next(next).Sub(next)
+}
+
+// GetRuns returns the status of the most recent runs, newest first.
+func GetRuns() []RunStatus {
+	runLock.Lock()
+	history := append([]*Run(nil), runs...)
+	runLock.Unlock()
+
+	statuses := make([]RunStatus, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		statuses = append(statuses, history[i].Status())
+	}
+	return statuses
+}
+
+// GetRun returns the run with the given ID, if it is still part of the history.
+func GetRun(id string) (*Run, bool) {
+	runLock.Lock()
+	defer runLock.Unlock()
+	for _, run := range runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return nil, false
+}
+
+// Start begins a run of the named checks. A check can only be part of one run at a time: when one of
+// them is already running, Start fails with ErrBusy, unless skipBusy is set, in which case the busy
+// checks are skipped and reported in Run.Skipped.
+func Start(trigger string, names []string, skipBusy bool) (*Run, error) {
+	selected, err := lookup(names)
+	if err != nil {
+		return nil, err
+	}
+
+	runLock.Lock()
+	var acquired []Check
+	var skipped []string
+	for _, check := range selected {
+		if owner, busy := running[check.Name]; busy {
+			if !skipBusy {
+				runLock.Unlock()
+				return nil, fmt.Errorf("%w: %s is part of run %s", ErrBusy, check.Name, owner)
+			}
+			skipped = append(skipped, check.Name)
+			continue
+		}
+		acquired = append(acquired, check)
+	}
+	if len(acquired) == 0 {
+		runLock.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrBusy, strings.Join(skipped, ", "))
+	}
+
+	run := &Run{
+		ID:        newRunID(),
+		Trigger:   trigger,
+		Skipped:   skipped,
+		StartTime: time.Now(),
+		done:      make(chan struct{}),
+	}
+	for _, check := range acquired {
+		running[check.Name] = run.ID
+		run.Checks = append(run.Checks, check.Name)
+	}
+	runs = append(runs, run)
+	if len(runs) > runHistory {
+		runs = runs[len(runs)-runHistory:]
+	}
+	runLock.Unlock()
+
+	if len(skipped) > 0 {
+		log.Printf("Run %s skips checks that are already running: %s", run.ID, strings.Join(skipped, ", "))
+	}
+	go execute(run, acquired)
+	return run, nil
+}
+
+// execute runs the acquired checks, publishes their results and releases them.
+func execute(run *Run, selected []Check) {
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	defer cancel()
+	ctx, span := telemetry.StartSpan(ctx, "Run",
+		attribute.String("run.id", run.ID),
+		attribute.String("run.trigger", run.Trigger),
+		attribute.StringSlice("run.checks", run.Checks),
+	)
+	log.Printf("Run %s (%s) started: %s", run.ID, run.Trigger, strings.Join(run.Checks, ", "))
+
+	results := checks.NewResults()
+	clusters, err := loadClusters(ctx, selected)
+	if err != nil {
+		log.Errorf("Run %s failed to load clusters: %v", run.ID, err)
+		metrics.ErrorCounter.WithLabelValues(config.Current().ClusterName, "clusters", "load_error").Inc()
+		run.addError(fmt.Errorf("loading clusters: %w", err))
+	}
+	run.mu.Lock()
+	for _, cluster := range clusters {
+		run.clusters = append(run.clusters, cluster.Name)
+	}
+	run.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, check := range selected {
+		targets := clusters
+		if !check.PerCluster {
+			targets = []k8s.Cluster{{Name: config.Current().ClusterName, Local: true}}
+		}
+		for _, cluster := range targets {
+			wg.Add(1)
+			go func(check Check, cluster k8s.Cluster) {
+				defer wg.Done()
+				runCheck(ctx, run, check, cluster, results)
+			}(check, cluster)
+		}
+	}
+	wg.Wait()
+
+	// Drop the results of clusters that are no longer configured, keeping those reported by agents
+	var retain []string
+	if clusters != nil {
+		retain = append(run.clusterNames(), hub.ReportedClusters()...)
+	}
+	results.Publish(retain)
+	if clusters != nil {
+		pruneCheckTimes(run.clusterNames())
+	}
+
+	// A scheduled run that lost the Lease while it was running leaves the side effects to the new leader
+	scheduled := run.Trigger == TriggerSchedule
+	if scheduled && !leader.IsLeader() {
+		log.Printf("Run %s finished after leadership was lost; skipping reports, notifications and hub push.", run.ID)
+		scheduled = false
+	}
+	if scheduled && config.Current().ReportsEnabled {
+		for _, cluster := range clusters {
+			syncReports(ctx, run, cluster)
+		}
+	}
+	if scheduled && clusters != nil {
+		sendNotifications(ctx, run)
+	}
+	if scheduled && config.Current().HubURL != "" && clusters != nil {
+		pushToHub(ctx, run)
+	}
+	finish(run)
+
+	var runErr error
+	if runErrors := run.Status().Errors; len(runErrors) > 0 {
+		runErr = errors.New(strings.Join(runErrors, "; "))
+	}
+	telemetry.EndSpan(span, runErr)
+}
+
+// pruneCheckTimes drops the last_check_time series of clusters that are no longer monitored, so that
+// they do not look stale forever.
+func pruneCheckTimes(monitored []string) {
+	keep := map[string]bool{config.Current().ClusterName: true}
+	for _, name := range monitored {
+		keep[name] = true
+	}
+	timedLock.Lock()
+	defer timedLock.Unlock()
+	for name := range timedClusters {
+		if !keep[name] {
+			metrics.LastCheckTime.DeletePartialMatch(prometheus.Labels{"cluster": name})
+			delete(timedClusters, name)
+		}
+	}
+}
+
+// loadClusters returns the monitored clusters when one of the checks runs per cluster.
+func loadClusters(ctx context.Context, selected []Check) ([]k8s.Cluster, error) {
+	for _, check := range selected {
+		if check.PerCluster {
+			return k8s.LoadClusters(ctx)
+		}
+	}
+	return nil, nil
+}
+
+// runCheck executes check against cluster and records its outcome.
+func runCheck(ctx context.Context, run *Run, check Check, cluster k8s.Cluster, results *checks.Results) {
+	log.Printf("Run %s: running %s in cluster %s...", run.ID, check.Description, cluster.Name)
+	ctx, span := telemetry.StartSpan(ctx, "Check "+check.Name,
+		attribute.String("check", check.Name),
+		attribute.String("cluster", cluster.Name),
+	)
+	result := CheckResult{Check: check.Name, Cluster: cluster.Name, StartTime: time.Now()}
+	var err error
+	defer func() {
+		span.SetAttributes(attribute.Int("objects", result.Objects))
+		telemetry.EndSpan(span, err)
+	}()
+	if check.PerCluster {
+		err = withRetry(ctx, func() error {
+			return check.Run(ctx, cluster, results)
+		})
+	} else {
+		err = check.Run(ctx, cluster, results)
+	}
+	if err != nil {
+		log.Errorf("Run %s: %s failed in cluster %s: %v", run.ID, check.Description, cluster.Name, err)
+		metrics.ErrorCounter.WithLabelValues(cluster.Name, check.ErrorType, "check_error").Inc()
+		result.Error = err.Error()
+	}
+	timedLock.Lock()
+	timedClusters[cluster.Name] = true
+	metrics.LastCheckTime.WithLabelValues(cluster.Name, check.TimeName).SetToCurrentTime()
+	timedLock.Unlock()
+	result.EndTime = time.Now()
+	metrics.CheckDuration.WithLabelValues(cluster.Name, check.Name).Observe(result.EndTime.Sub(result.StartTime).Seconds())
+	result.Objects = results.Count(check.Name, cluster.Name)
+	run.addResult(result)
+}
+
+// syncReports writes the results of cluster as CertificateReports.
+func syncReports(ctx context.Context, run *Run, cluster k8s.Cluster) {
+	if err := reports.Sync(ctx, cluster); err != nil {
+		log.Errorf("Run %s failed to write CertificateReports in cluster %s: %v", run.ID, cluster.Name, err)
+		metrics.ErrorCounter.WithLabelValues(cluster.Name, "reports", "sync_error").Inc()
+		run.addError(fmt.Errorf("writing reports in cluster %s: %w", cluster.Name, err))
+	}
+}
+
+// sendNotifications sends the findings of the run's clusters to the configured receivers.
+func sendNotifications(ctx context.Context, run *Run) {
+	if err := notify.Notify(ctx, run.clusterNames()); err != nil {
+		log.Errorf("Run %s failed to send notifications: %v", run.ID, err)
+		metrics.ErrorCounter.WithLabelValues(config.Current().ClusterName, "notify", "send_error").Inc()
+		run.addError(fmt.Errorf("sending notifications: %w", err))
+	}
+}
+
+// pushToHub sends the results of the run's clusters to the hub.
+func pushToHub(ctx context.Context, run *Run) {
+	report := hub.Report{Agent: config.Current().ClusterName, GeneratedAt: time.Now(), Snapshot: checks.TakeSnapshot(run.clusterNames())}
+	err := withRetry(ctx, func() error {
+		return hub.Push(ctx, config.Current().HubURL, config.Current().HubSharedSecret, report)
+	})
+	if err != nil {
+		log.Errorf("Run %s failed to push results to hub %s: %v", run.ID, config.Current().HubURL, err)
+		metrics.ErrorCounter.WithLabelValues(config.Current().ClusterName, "hub-push", "push_error").Inc()
+		run.addError(fmt.Errorf("pushing to hub: %w", err))
+	}
+}
+
+// finish releases the checks of run and records its completion.
+func finish(run *Run) {
+	run.mu.Lock()
+	run.endTime = time.Now()
+	endTime := run.endTime
+	errCount := len(run.errors)
+	outcomes := make(map[string][]CheckResult, len(run.Checks))
+	for _, result := range run.results {
+		outcomes[result.Check] = append(outcomes[result.Check], result)
+	}
+	runErrors := strings.Join(run.errors, "; ")
+	run.mu.Unlock()
+
+	runLock.Lock()
+	for _, name := range run.Checks {
+		delete(running, name)
+		updateCheckState(run, name, endTime, outcomes[name], runErrors)
+	}
+	runLock.Unlock()
+	close(run.done)
+
+	if errCount == 0 {
+		log.Printf("Run %s completed successfully in %s.", run.ID, endTime.Sub(run.StartTime).Round(time.Millisecond))
+	} else {
+		log.Printf("Run %s completed with %d errors in %s.", run.ID, errCount, endTime.Sub(run.StartTime).Round(time.Millisecond))
+	}
+}
+
+// updateCheckState records the outcome of check in run. A check without results failed before it
+// could run, for instance because the clusters could not be loaded. runLock must be held.
+func updateCheckState(run *Run, name string, endTime time.Time, results []CheckResult, runErrors string) {
+	state := checkStates[name]
+	if state == nil {
+		state = &CheckState{Check: name}
+		if check, err := lookup([]string{name}); err == nil {
+			state.Scheduled = check[0].Scheduled
+		}
+		checkStates[name] = state
+	}
+
+	var failures []string
+	localSuccess := false
+	for _, result := range results {
+		if result.Error != "" {
+			failures = append(failures, result.Error)
+		} else if result.Cluster == config.Current().ClusterName {
+			localSuccess = true
+		}
+	}
+	if len(results) == 0 {
+		failures = append(failures, runErrors)
+		if runErrors == "" {
+			failures = []string{"no cluster was checked"}
+		}
+	}
+
+	state.LastRun = run.ID
+	state.LastRunTime = &endTime
+	state.LastError = strings.Join(failures, "; ")
+	if len(failures) == 0 {
+		state.LastSuccess = &endTime
+	}
+	if localSuccess {
+		state.LastLocalSuccess = &endTime
+	}
+}
+
+// runExternal probes the statically configured external endpoints.
+func runExternal(ctx context.Context, _ k8s.Cluster, results *checks.Results) error {
+	return checks.CheckExternalEndpoints(ctx, config.Current().ExternalTargets, results)
+}
+
+// lookup resolves check names against the registry.
+func lookup(names []string) ([]Check, error) {
+	selected := make([]Check, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		found := false
+		for _, check := range registry {
+			if check.Name == name {
+				selected = append(selected, check)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownCheck, name)
+		}
+	}
+	return selected, nil
+}
+
+// newRunID returns a random identifier for a run.
+func newRunID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
+
+// withRetry implements retry logic for transient failures
+func withRetry(ctx context.Context, fn func() error) error {
+	backoff := time.Second
+	for attempts := 0; attempts < 3; attempts++ {
+		if err := fn(); err != nil {
+			if attempts == 2 {
+				return err
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+				backoff *= 2
+				continue
+			}
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package coordinator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// selfSignedPEM returns a PEM-encoded self-signed certificate.
+func selfSignedPEM(t *testing.T) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tls.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// startAPIServer serves a cluster holding one TLS secret and writes a kubeconfig pointing at it.
+func startAPIServer(t *testing.T) string {
+	t.Helper()
+	secrets := corev1.SecretList{
+		TypeMeta: metav1.TypeMeta{Kind: "SecretList", APIVersion: "v1"},
+		Items: []corev1.Secret{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "tls"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{"tls.crt": selfSignedPEM(t)},
+		}},
+	}
+	namespaces := corev1.NamespaceList{TypeMeta: metav1.TypeMeta{Kind: "NamespaceList", APIVersion: "v1"}}
+
+	mux := http.NewServeMux()
+	serve := func(path string, value interface{}) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(value)
+		})
+	}
+	serve("/api/v1/secrets", secrets)
+	serve("/api/v1/namespaces", namespaces)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	content := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: %s
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user: {}
+`, server.URL)
+	if err := os.WriteFile(kubeconfig, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return kubeconfig
+}
+
+func TestRunEmitsSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	endpoint := httptest.NewTLSServer(http.NotFoundHandler())
+	defer endpoint.Close()
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	kubeconfig := startAPIServer(t)
+	args := []string{"--cluster-name=test", "--kubeconfig=" + kubeconfig, "--external-targets=" + endpoint.Listener.Addr().String()}
+	if _, err := config.LoadConfiguration(args); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := k8s.ConnectToK8s(); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := Start(TriggerManual, []string{"secrets", "external"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-run.Done()
+	if errs := run.Status().Errors; len(errs) > 0 {
+		t.Fatalf("run failed: %v", errs)
+	}
+
+	// The Run span ends right after the run is marked as done
+	var spans []sdktrace.ReadOnlySpan
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		spans = recorder.Ended()
+		if findSpan(spans, "Run") != nil {
+			break
+		}
+	}
+
+	root := findSpan(spans, "Run")
+	if root == nil {
+		t.Fatal("no Run span was emitted")
+	}
+	for _, name := range []string{"Check secrets", "List secrets", "Parse certificates", "Check external", "TLS handshake"} {
+		span := findSpan(spans, name)
+		if span == nil {
+			t.Errorf("no %q span was emitted", name)
+			continue
+		}
+		if span.SpanContext().TraceID() != root.SpanContext().TraceID() {
+			t.Errorf("span %q is not part of the trace of the run", name)
+		}
+	}
+}
+
+// findSpan returns the first span called name.
+func findSpan(spans []sdktrace.ReadOnlySpan, name string) sdktrace.ReadOnlySpan {
+	for _, span := range spans {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
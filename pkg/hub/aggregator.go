@@ -0,0 +1,198 @@
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+)
+
+// AgentStatus represents an agent that has pushed reports to the hub.
+type AgentStatus struct {
+	Agent       string
+	Address     string
+	Clusters    []string
+	GeneratedAt time.Time
+	LastSeen    time.Time
+	Status      string
+}
+
+var (
+	agents    = map[string]*AgentStatus{}
+	agentLock sync.Mutex
+)
+
+// GetAgentStatuses returns a snapshot of the agents known to the hub, sorted by name.
+func GetAgentStatuses() []AgentStatus {
+	agentLock.Lock()
+	defer agentLock.Unlock()
+
+	statuses := make([]AgentStatus, 0, len(agents))
+	for _, agent := range agents {
+		status := *agent
+		status.Clusters = append([]string(nil), agent.Clusters...)
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Agent < statuses[j].Agent })
+	return statuses
+}
+
+// ReportedClusters returns the clusters whose statuses were pushed by agents, so that the hub keeps
+// them when it prunes the statuses of its own clusters.
+func ReportedClusters() []string {
+	agentLock.Lock()
+	defer agentLock.Unlock()
+
+	var clusters []string
+	for _, agent := range agents {
+		clusters = append(clusters, agent.Clusters...)
+	}
+	return clusters
+}
+
+// CheckAgents flags agents that have not reported within HUB_STALE_AFTER. Their last statuses are
+// kept so that the fleet view still shows what they reported.
+func CheckAgents() {
+	agentLock.Lock()
+	defer agentLock.Unlock()
+
+	for _, agent := range agents {
+		up := 1.0
+		if time.Since(agent.LastSeen) > config.Current().HubStaleAfter {
+			up = 0
+			if agent.Status != "stale" {
+				log.Warnf("Agent %s stopped reporting; last report received at %s", agent.Agent, agent.LastSeen.Format(time.RFC3339))
+			}
+			agent.Status = "stale"
+		}
+		metrics.HubAgentUp.WithLabelValues(config.Current().ClusterName, agent.Agent).Set(up)
+	}
+}
+
+// PushHandler accepts signed reports from agents and merges them into the fleet view.
+func PushHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxReportSize))
+	if err != nil {
+		http.Error(w, "Report too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	agentName := r.Header.Get(AgentHeader)
+	key, allowed, err := agentKey(config.Current().HubAgentKeysDir, agentName)
+	if err == nil {
+		err = verify(key, agentName, r.Header.Get(TimestampHeader), r.Header.Get(SignatureHeader), body)
+	}
+	if err != nil {
+		log.Warnf("Rejected report from %s (%s): %v", agentName, r.RemoteAddr, err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var report Report
+	if err := json.Unmarshal(body, &report); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid report: %v", err), http.StatusBadRequest)
+		return
+	}
+	if report.Agent != agentName {
+		http.Error(w, "Report agent does not match the signed agent", http.StatusBadRequest)
+		return
+	}
+
+	for _, cluster := range report.Snapshot.Clusters {
+		if !containsCluster(allowed, cluster) {
+			log.Warnf("Rejected report from agent %s: cluster %s is not listed in its key file", agentName, cluster)
+			http.Error(w, fmt.Sprintf("Agent %s may not report cluster %s", agentName, cluster), http.StatusForbidden)
+			return
+		}
+	}
+
+	if err := acceptReport(report, remoteHost(r)); err != nil {
+		log.Warnf("Rejected report from agent %s: %v", agentName, err)
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprint(w, "Report accepted.")
+}
+
+// acceptReport validates the clusters claimed by report and replaces the agent's previous statuses.
+func acceptReport(report Report, address string) error {
+	agentLock.Lock()
+	defer agentLock.Unlock()
+
+	previous := agents[report.Agent]
+	if previous != nil && !report.GeneratedAt.After(previous.GeneratedAt) {
+		return fmt.Errorf("report generated at %s is not newer than the last accepted one", report.GeneratedAt.Format(time.RFC3339Nano))
+	}
+
+	for _, cluster := range report.Snapshot.Clusters {
+		if cluster == "" {
+			return fmt.Errorf("report contains a cluster without a name")
+		}
+		if cluster == config.Current().ClusterName {
+			return fmt.Errorf("cluster %s is monitored by the hub itself", cluster)
+		}
+		for _, other := range agents {
+			if other.Agent != report.Agent && containsCluster(other.Clusters, cluster) {
+				return fmt.Errorf("cluster %s is already reported by agent %s", cluster, other.Agent)
+			}
+		}
+	}
+
+	var replaced []string
+	if previous != nil {
+		replaced = previous.Clusters
+	}
+	checks.ApplySnapshot(report.Snapshot, replaced)
+
+	now := time.Now()
+	agents[report.Agent] = &AgentStatus{
+		Agent:       report.Agent,
+		Address:     address,
+		Clusters:    append([]string(nil), report.Snapshot.Clusters...),
+		GeneratedAt: report.GeneratedAt,
+		LastSeen:    now,
+		Status:      "reporting",
+	}
+	if previous != nil && previous.Status == "stale" {
+		log.Printf("Agent %s resumed reporting", report.Agent)
+	}
+	log.Printf("Accepted report from agent %s covering %d clusters", report.Agent, len(report.Snapshot.Clusters))
+
+	metrics.HubAgentLastSeen.WithLabelValues(config.Current().ClusterName, report.Agent).Set(float64(now.Unix()))
+	metrics.HubAgentUp.WithLabelValues(config.Current().ClusterName, report.Agent).Set(1)
+	return nil
+}
+
+// containsCluster reports whether clusters contains name.
+func containsCluster(clusters []string, name string) bool {
+	for _, cluster := range clusters {
+		if cluster == name {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHost returns the host part of the request's remote address.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,140 @@
+// pkg/hub/hub.go
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+)
+
+const (
+	// PushPath is the hub endpoint that accepts agent reports.
+	PushPath = "/api/v1/agents/push"
+
+	// AgentHeader, TimestampHeader and SignatureHeader authenticate a pushed report.
+	AgentHeader     = "X-KubeCertWatch-Agent"
+	TimestampHeader = "X-KubeCertWatch-Timestamp"
+	SignatureHeader = "X-KubeCertWatch-Signature"
+
+	// maxClockSkew bounds the age of a signed request accepted by the hub.
+	maxClockSkew = 5 * time.Minute
+	// maxReportSize bounds the size of a pushed report.
+	maxReportSize = 32 << 20
+)
+
+var (
+	log = logging.SetupLogging()
+
+	pushClient = &http.Client{Timeout: 30 * time.Second}
+)
+
+// Report is the snapshot an agent pushes to the hub after every run.
+type Report struct {
+	Agent       string          `json:"agent"`
+	GeneratedAt time.Time       `json:"generatedAt"`
+	Snapshot    checks.Snapshot `json:"snapshot"`
+}
+
+// Push signs report with secret, the key of the agent, and sends it to the hub at hubURL.
+func Push(ctx context.Context, hubURL, secret string, report Report) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(hubURL, "/")+PushPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(AgentHeader, report.Agent)
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, sign(secret, report.Agent, timestamp, body))
+
+	resp, err := pushClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("hub rejected report with %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	log.Debugf("Pushed snapshot of %d clusters to %s", len(report.Snapshot.Clusters), hubURL)
+	return nil
+}
+
+// sign returns the HMAC-SHA256 signature of a report, bound to the agent name and request timestamp.
+func sign(secret, agent, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(agent + "\n" + timestamp + "\n"))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// agentNamePattern matches the agent names that can have a key file.
+var agentNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// agentKey reads the key of agent from its file in dir, so that every agent signs with its own key
+// and cannot push reports under the name of another. The first line of the file is the key; every
+// further non-empty line names a cluster the agent may report besides the one named after itself.
+// Keys are read on every request, so that rotated keys of a mounted Secret apply without a restart.
+func agentKey(dir, agent string) (string, []string, error) {
+	if !agentNamePattern.MatchString(agent) {
+		return "", nil, fmt.Errorf("invalid agent name %q", agent)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, agent))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", nil, fmt.Errorf("unknown agent %q", agent)
+		}
+		return "", nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	key := strings.TrimSpace(lines[0])
+	if key == "" {
+		return "", nil, fmt.Errorf("key of agent %q is empty", agent)
+	}
+	clusters := []string{agent}
+	for _, line := range lines[1:] {
+		if cluster := strings.TrimSpace(line); cluster != "" {
+			clusters = append(clusters, cluster)
+		}
+	}
+	return key, clusters, nil
+}
+
+// verify checks the signature and freshness of a pushed report.
+func verify(secret, agent, timestamp, signature string, body []byte) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("timestamp is %s away from the hub clock", skew.Round(time.Second))
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(secret, agent, timestamp, body))) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+)
+
+// startHub runs the aggregator behind a local HTTP server, with one key file per agent in keys. A key
+// may be followed by lines naming the other clusters the agent may report.
+func startHub(t *testing.T, keys map[string]string) *httptest.Server {
+	t.Helper()
+	dir := t.TempDir()
+	for agent, key := range keys {
+		if err := os.WriteFile(filepath.Join(dir, agent), []byte(key+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := config.LoadConfiguration([]string{"--cluster-name=hub", "--hub-enabled", "--hub-agent-keys-dir=" + dir}); err != nil {
+		t.Fatal(err)
+	}
+
+	agentLock.Lock()
+	agents = map[string]*AgentStatus{}
+	agentLock.Unlock()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(PushPath, PushHandler)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// report returns a snapshot of agent holding one TLS secret in each of clusters.
+func report(agent string, clusters ...string) Report {
+	snapshot := checks.Snapshot{Clusters: clusters}
+	for _, cluster := range clusters {
+		snapshot.Secrets = append(snapshot.Secrets, checks.SecretStatus{Cluster: cluster, Namespace: "default", SecretName: "tls", Status: "valid"})
+	}
+	return Report{Agent: agent, GeneratedAt: time.Now(), Snapshot: snapshot}
+}
+
+func TestPushAcceptsSignedReport(t *testing.T) {
+	server := startHub(t, map[string]string{"edge-1": "key-1"})
+
+	if err := Push(context.Background(), server.URL, "key-1", report("edge-1", "edge-1")); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	statuses := GetAgentStatuses()
+	if len(statuses) != 1 || statuses[0].Agent != "edge-1" || statuses[0].Status != "reporting" {
+		t.Fatalf("agents = %+v, want edge-1 reporting", statuses)
+	}
+	found := false
+	for _, status := range checks.GetSecretStatuses() {
+		if status.Cluster == "edge-1" && status.SecretName == "tls" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("the secret reported by edge-1 is missing from the hub statuses")
+	}
+}
+
+func TestPushRejectsForeignKeys(t *testing.T) {
+	server := startHub(t, map[string]string{"edge-1": "key-1", "edge-2": "key-2"})
+
+	tests := []struct {
+		name   string
+		key    string
+		report Report
+	}{
+		{name: "agent signing with the key of another agent", key: "key-2", report: report("edge-1", "edge-1")},
+		{name: "agent without a key file", key: "key-1", report: report("edge-3", "edge-3")},
+		{name: "agent name that is not a file name", key: "key-1", report: report("../edge-1", "edge-1")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Push(context.Background(), server.URL, test.key, test.report)
+			if err == nil || !strings.Contains(err.Error(), "401") {
+				t.Fatalf("Push error = %v, want 401 Unauthorized", err)
+			}
+		})
+	}
+	if statuses := GetAgentStatuses(); len(statuses) != 0 {
+		t.Errorf("agents = %+v, want none", statuses)
+	}
+}
+
+func TestPushRejectsClaimedCluster(t *testing.T) {
+	server := startHub(t, map[string]string{"edge-1": "key-1\nshared", "edge-2": "key-2\nshared"})
+
+	if err := Push(context.Background(), server.URL, "key-1", report("edge-1", "edge-1", "shared")); err != nil {
+		t.Fatalf("Push from edge-1: %v", err)
+	}
+	err := Push(context.Background(), server.URL, "key-2", report("edge-2", "shared"))
+	if err == nil || !strings.Contains(err.Error(), "409") {
+		t.Fatalf("Push from edge-2 error = %v, want 409 Conflict", err)
+	}
+}
+
+func TestPushRejectsUnlistedCluster(t *testing.T) {
+	server := startHub(t, map[string]string{"edge-1": "key-1\nedge-1-remote", "edge-2": "key-2"})
+
+	if err := Push(context.Background(), server.URL, "key-1", report("edge-1", "edge-1", "edge-1-remote")); err != nil {
+		t.Fatalf("Push of listed clusters: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		report Report
+	}{
+		{name: "cluster named after another agent", key: "key-1", report: report("edge-1", "edge-1", "edge-2")},
+		{name: "cluster missing from the key file", key: "key-2", report: report("edge-2", "edge-2", "production")},
+		{name: "cluster listed for another agent", key: "key-2", report: report("edge-2", "edge-1-remote")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := Push(context.Background(), server.URL, test.key, test.report)
+			if err == nil || !strings.Contains(err.Error(), "403") {
+				t.Fatalf("Push error = %v, want 403 Forbidden", err)
+			}
+		})
+	}
+	if statuses := GetAgentStatuses(); len(statuses) != 1 || statuses[0].Agent != "edge-1" {
+		t.Errorf("agents = %+v, want only edge-1", statuses)
+	}
+}
@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// ClusterSecretLabel marks Secrets that hold the kubeconfig of a remote cluster; its value is the cluster name.
+	ClusterSecretLabel = "kubecertwatch.io/cluster"
+	// clusterSecretKey is the Secret data key that holds the kubeconfig of a remote cluster.
+	clusterSecretKey = "kubeconfig"
+)
+
+// Cluster is a Kubernetes cluster monitored by KubeCertWatch.
+type Cluster struct {
+	Name      string
+	Config    *rest.Config
+	Clientset *kubernetes.Clientset
+	// Local is set for the cluster KubeCertWatch runs in, whose Service network is reachable.
+	Local bool
+}
+
+// DynamicClient creates a dynamic client for the cluster.
+func (c Cluster) DynamicClient() (dynamic.Interface, error) {
+	client, err := dynamic.NewForConfig(c.Config)
+	if err != nil {
+		log.Errorf("Failed to create dynamic client for cluster %s: %v", c.Name, err)
+		return nil, err
+	}
+	return client, nil
+}
+
+// LocalCluster returns the cluster connected by ConnectToK8s, named after CLUSTER_NAME.
+func LocalCluster() (Cluster, error) {
+	kubeConfig, err := GetRestConfig()
+	if err != nil {
+		return Cluster{}, err
+	}
+	return Cluster{Name: config.Current().ClusterName, Config: kubeConfig, Clientset: localClientset, Local: true}, nil
+}
+
+// LoadClusters returns the local cluster followed by every remote cluster configured through
+// KUBECONFIG contexts, kubeconfig files and cluster Secrets. Remote clusters that cannot be
+// loaded are logged and skipped so that one broken credential does not stop the others.
+func LoadClusters(ctx context.Context) ([]Cluster, error) {
+	local, err := LocalCluster()
+	if err != nil {
+		return nil, err
+	}
+	clusters := []Cluster{local}
+	seen := map[string]bool{local.Name: true}
+
+	add := func(name string, kubeConfig *rest.Config, err error) {
+		if err == nil && seen[name] {
+			err = errors.New("duplicate cluster name")
+		}
+		if err == nil {
+			var clientset *kubernetes.Clientset
+			clientset, err = kubernetes.NewForConfig(kubeConfig)
+			if err == nil {
+				clusters = append(clusters, Cluster{Name: name, Config: kubeConfig, Clientset: clientset})
+				seen[name] = true
+				return
+			}
+		}
+		log.Errorf("Skipping cluster %s: %v", name, err)
+	}
+
+	for _, contextName := range config.Current().KubeConfigContexts {
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		if config.Current().KubeConfig != "" {
+			loadingRules.ExplicitPath = config.Current().KubeConfig
+		}
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+		kubeConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+		add(contextName, kubeConfig, err)
+	}
+
+	for _, cluster := range config.Current().ClusterKubeConfigs {
+		kubeConfig, err := clientcmd.BuildConfigFromFlags("", cluster.Path)
+		add(cluster.Name, kubeConfig, err)
+	}
+
+	if config.Current().ClusterSecretNamespace != "" {
+		secrets, err := local.Clientset.CoreV1().Secrets(config.Current().ClusterSecretNamespace).List(ctx, metav1.ListOptions{LabelSelector: ClusterSecretLabel})
+		if err != nil {
+			log.Errorf("Failed to list cluster Secrets in %s: %v", config.Current().ClusterSecretNamespace, err)
+		} else {
+			for _, secret := range secrets.Items {
+				name := secret.Labels[ClusterSecretLabel]
+				if name == "" {
+					name = secret.Name
+				}
+				kubeConfig, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[clusterSecretKey])
+				add(name, kubeConfig, err)
+			}
+		}
+	}
+
+	log.Debugf("Loaded %d clusters", len(clusters))
+	return clusters, nil
+}
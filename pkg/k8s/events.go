@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RecordEvent records a Kubernetes Event about the KubeCertWatch pod, so that operational problems show
+// up in `kubectl describe pod`. It does nothing when the client is not connected or POD_NAME is unset,
+// since callers log the message themselves, and only logs a warning when the Event cannot be created.
+func RecordEvent(ctx context.Context, eventType, reason, message string) {
+	podName := os.Getenv("POD_NAME")
+	if localClientset == nil || podName == "" {
+		return
+	}
+
+	namespace := PodNamespace()
+	now := metav1.NewTime(time.Now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", podName, now.UnixNano()),
+			Namespace: namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       podName,
+			Namespace:  namespace,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: "kubecertwatch"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := localClientset.CoreV1().Events(namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		log.Warnf("Failed to record %s event %s: %v", eventType, reason, err)
+	}
+}
@@ -1,14 +1,31 @@
 package k8s
 
 import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
 	"github.com/supporttools/KubeCertWatch/pkg/config"
 	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	apiextensionsclient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	aggregatorclient "k8s.io/kube-aggregator/pkg/client/clientset_generated/clientset"
+	gatewayclient "sigs.k8s.io/gateway-api/pkg/client/clientset/versioned"
 )
 
-var log = logging.SetupLogging()
+// namespaceFile holds the namespace of the pod when running in-cluster.
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+var (
+	log = logging.SetupLogging()
+
+	// restConfig and localClientset hold the result of the last successful ConnectToK8s call.
+	restConfig     *rest.Config
+	localClientset *kubernetes.Clientset
+)
 
 // ConnectToK8s connects to a Kubernetes cluster by checking the environment and configuration settings.
 func ConnectToK8s() (*kubernetes.Clientset, error) {
@@ -26,12 +43,13 @@ func ConnectToK8s() (*kubernetes.Clientset, error) {
 			return nil, err
 		}
 		log.Debug("Successfully created Kubernetes client using in-cluster configuration.")
+		restConfig, localClientset = kubeConfig, clientset
 		return clientset, nil
 	}
 	log.Warnf("In-cluster configuration failed: %v. Attempting to use KUBECONFIG.", err)
 
 	// Attempt to connect using KUBECONFIG environment variable
-	cfgKubeConfig := config.CFG.KubeConfig
+	cfgKubeConfig := config.Current().KubeConfig
 	if cfgKubeConfig != "" {
 		log.Debugf("KUBECONFIG environment variable is set: %s", cfgKubeConfig)
 		log.Debug("Attempting to build configuration from KUBECONFIG...")
@@ -44,6 +62,7 @@ func ConnectToK8s() (*kubernetes.Clientset, error) {
 				return nil, err
 			}
 			log.Debug("Successfully created Kubernetes client using KUBECONFIG.")
+			restConfig, localClientset = kubeConfig, clientset
 			return clientset, nil
 		}
 		log.Errorf("Failed to load configuration from KUBECONFIG (%s): %v", cfgKubeConfig, err)
@@ -55,3 +74,63 @@ func ConnectToK8s() (*kubernetes.Clientset, error) {
 	log.Error("All attempts to configure Kubernetes client failed. Ensure the environment or KUBECONFIG is set correctly.")
 	return nil, err
 }
+
+// GetRestConfig returns the REST configuration resolved by ConnectToK8s.
+func GetRestConfig() (*rest.Config, error) {
+	if restConfig == nil {
+		return nil, errors.New("kubernetes client is not connected; call ConnectToK8s first")
+	}
+	return restConfig, nil
+}
+
+// Ping verifies that the API server of the local cluster is reachable.
+func Ping(ctx context.Context) error {
+	if localClientset == nil {
+		return errors.New("kubernetes client is not connected")
+	}
+	_, err := localClientset.Discovery().RESTClient().Get().AbsPath("/version").DoRaw(ctx)
+	return err
+}
+
+// ConnectToAPIExtensions creates a client for the apiextensions.k8s.io API group.
+func ConnectToAPIExtensions(kubeConfig *rest.Config) (*apiextensionsclient.Clientset, error) {
+	client, err := apiextensionsclient.NewForConfig(kubeConfig)
+	if err != nil {
+		log.Errorf("Failed to create apiextensions client: %v", err)
+		return nil, err
+	}
+	return client, nil
+}
+
+// ConnectToAPIRegistration creates a client for the apiregistration.k8s.io API group.
+func ConnectToAPIRegistration(kubeConfig *rest.Config) (*aggregatorclient.Clientset, error) {
+	client, err := aggregatorclient.NewForConfig(kubeConfig)
+	if err != nil {
+		log.Errorf("Failed to create apiregistration client: %v", err)
+		return nil, err
+	}
+	return client, nil
+}
+
+// ConnectToGatewayAPI creates a client for the gateway.networking.k8s.io API group.
+func ConnectToGatewayAPI(kubeConfig *rest.Config) (*gatewayclient.Clientset, error) {
+	client, err := gatewayclient.NewForConfig(kubeConfig)
+	if err != nil {
+		log.Errorf("Failed to create Gateway API client: %v", err)
+		return nil, err
+	}
+	return client, nil
+}
+
+// PodNamespace returns the namespace of the pod, falling back to "default" outside a cluster.
+func PodNamespace() string {
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	if data, err := os.ReadFile(namespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace
+		}
+	}
+	return "default"
+}
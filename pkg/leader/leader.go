@@ -0,0 +1,139 @@
+// pkg/leader/leader.go
+package leader
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+var (
+	log = logging.SetupLogging()
+
+	stateLock     sync.Mutex
+	enabled       bool
+	identity      string
+	leading       = true
+	currentLeader string
+	clientset     *kubernetes.Clientset
+)
+
+// Status describes the leadership of this instance.
+type Status struct {
+	Enabled       bool   `json:"enabled"`
+	Identity      string `json:"identity"`
+	Leader        bool   `json:"leader"`
+	CurrentLeader string `json:"currentLeader"`
+}
+
+// IsLeader reports whether this instance should run scheduled checks. It is always true when leader
+// election is disabled.
+func IsLeader() bool {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	return leading
+}
+
+// GetStatus returns the leadership of this instance.
+func GetStatus() Status {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	return Status{Enabled: enabled, Identity: identity, Leader: leading, CurrentLeader: currentLeader}
+}
+
+// Start campaigns for the Lease configured by LEADER_ELECTION_* until ctx is cancelled.
+// onStartedLeading is called every time this instance acquires the Lease.
+func Start(ctx context.Context, client *kubernetes.Clientset, onStartedLeading func()) error {
+	id := podIdentity()
+	namespace := config.Current().LeaderElectionNamespace
+	if namespace == "" {
+		namespace = k8s.PodNamespace()
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      config.Current().LeaderElectionLeaseName,
+			Namespace: namespace,
+		},
+		Client:     client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: id},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   config.Current().LeaderElectionLeaseDuration,
+		RenewDeadline:   config.Current().LeaderElectionRenewDeadline,
+		RetryPeriod:     config.Current().LeaderElectionRetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            config.Current().LeaderElectionLeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Printf("Acquired Lease %s/%s; running scheduled checks.", namespace, lock.LeaseMeta.Name)
+				setLeading(true)
+				if onStartedLeading != nil {
+					go onStartedLeading()
+				}
+			},
+			OnStoppedLeading: func() {
+				log.Printf("Lost Lease %s/%s; scheduled checks are paused.", namespace, lock.LeaseMeta.Name)
+				setLeading(false)
+			},
+			OnNewLeader: func(newLeader string) {
+				stateLock.Lock()
+				currentLeader = newLeader
+				stateLock.Unlock()
+				if newLeader != id {
+					log.Printf("Current leader is %s.", newLeader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	stateLock.Lock()
+	enabled, identity, leading, clientset = true, id, false, client
+	stateLock.Unlock()
+
+	log.Printf("Leader election enabled as %s using Lease %s/%s.", id, namespace, config.Current().LeaderElectionLeaseName)
+	go func() {
+		// Run returns when leadership is lost; campaign again until shutdown
+		for ctx.Err() == nil {
+			elector.Run(ctx)
+			select {
+			case <-ctx.Done():
+			case <-time.After(config.Current().LeaderElectionRetryPeriod):
+			}
+		}
+	}()
+	return nil
+}
+
+// setLeading records whether this instance holds the Lease.
+func setLeading(value bool) {
+	stateLock.Lock()
+	defer stateLock.Unlock()
+	leading = value
+}
+
+// podIdentity returns the pod name, falling back to the hostname.
+func podIdentity() string {
+	if name := os.Getenv("POD_NAME"); name != "" {
+		return name
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "kubecertwatch"
+	}
+	return hostname
+}
@@ -0,0 +1,75 @@
+package leader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SnapshotPath is the path of the admin server endpoint that followers replicate from.
+const SnapshotPath = "/api/v1/snapshot"
+
+var replicateClient = &http.Client{Timeout: 30 * time.Second}
+
+// Replicate copies the statuses of the current leader into this follower so that its pages, API and
+// metrics show the leader's results. It does nothing on the leader or while no leader is known.
+func Replicate() {
+	status := GetStatus()
+	if !status.Enabled || status.Leader || status.CurrentLeader == "" || status.CurrentLeader == status.Identity {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	snapshot, err := fetchSnapshot(ctx, status.CurrentLeader)
+	if err != nil {
+		log.Warnf("Failed to replicate statuses from leader %s: %v", status.CurrentLeader, err)
+		return
+	}
+	checks.ApplySnapshot(snapshot, checks.Clusters())
+	log.Debugf("Replicated statuses of %d clusters from leader %s", len(snapshot.Clusters), status.CurrentLeader)
+}
+
+// fetchSnapshot downloads the statuses from the admin server of the leader pod.
+func fetchSnapshot(ctx context.Context, leaderPod string) (checks.Snapshot, error) {
+	stateLock.Lock()
+	client := clientset
+	stateLock.Unlock()
+
+	pod, err := client.CoreV1().Pods(k8s.PodNamespace()).Get(ctx, leaderPod, metav1.GetOptions{})
+	if err != nil {
+		return checks.Snapshot{}, err
+	}
+	if pod.Status.PodIP == "" {
+		return checks.Snapshot{}, fmt.Errorf("pod %s has no IP", leaderPod)
+	}
+
+	url := "http://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(config.Current().MetricsPort)) + SnapshotPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return checks.Snapshot{}, err
+	}
+	resp, err := replicateClient.Do(req)
+	if err != nil {
+		return checks.Snapshot{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return checks.Snapshot{}, fmt.Errorf("leader returned %s", resp.Status)
+	}
+
+	var snapshot checks.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snapshot); err != nil {
+		return checks.Snapshot{}, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	return snapshot, nil
+}
@@ -1,32 +1,280 @@
 package metrics
 
 import (
+	"net/http"
+	"strings"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Series is one sample of a GaugeFamily.
+type Series struct {
+	Labels []string
+	Value  float64
+}
+
+// GaugeFamily is a gauge vector whose series are replaced as a whole after every run, so that the
+// series of objects that no longer exist are removed instead of keeping their last value.
+type GaugeFamily struct {
+	*prometheus.GaugeVec
+
+	mu     sync.Mutex
+	series map[string][]string
+}
+
+// newGaugeFamily creates a GaugeFamily.
+func newGaugeFamily(opts prometheus.GaugeOpts, labels []string) *GaugeFamily {
+	return &GaugeFamily{GaugeVec: prometheus.NewGaugeVec(opts, labels), series: map[string][]string{}}
+}
+
+// Replace sets the given series and deletes those set by the previous call that are not part of series.
+func (f *GaugeFamily) Replace(series []Series) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current := make(map[string][]string, len(series))
+	for _, s := range series {
+		f.WithLabelValues(s.Labels...).Set(s.Value)
+		current[strings.Join(s.Labels, "\xff")] = s.Labels
+	}
+	for key, labels := range f.series {
+		if _, ok := current[key]; !ok {
+			f.DeleteLabelValues(labels...)
+		}
+	}
+	f.series = current
+}
+
 var (
 	// LastCheckTime tracks when each check was last run
 	LastCheckTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
 		Name: "last_check_time",
 		Help: "The last time the check was run",
-	}, []string{"check_name"})
+	}, []string{"cluster", "check_name"})
 
 	// ErrorCounter tracks the number of errors encountered
 	ErrorCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: "certificate_check_errors_total",
 		Help: "Total number of errors encountered during certificate checks",
-	}, []string{"check_type", "error_type"})
+	}, []string{"cluster", "check_type", "error_type"})
+
+	// CheckDuration tracks how long each check takes per cluster
+	CheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "check_duration_seconds",
+		Help:    "Duration of a check in one cluster, including retries",
+		Buckets: []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"cluster", "check"})
+
+	// CheckedObjects counts the objects examined by each check by status
+	CheckedObjects = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "checked_objects",
+		Help: "Number of objects examined by the last run of a check, by status",
+	}, []string{"cluster", "check", "status"})
+
+	// TLSSecretNotAfter tracks the expiration of certificates stored in TLS secrets
+	TLSSecretNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "tls_secret_not_after_timestamp_seconds",
+		Help: "Unix time at which the certificate stored in a TLS secret expires",
+	}, []string{"cluster", "namespace", "secret"})
+
+	// TLSSecretStatus exposes the status of TLS secrets
+	TLSSecretStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "tls_secret_status",
+		Help: "Current status of a TLS secret (always 1)",
+	}, []string{"cluster", "namespace", "secret", "status"})
+
+	// CertManagerCertificateNotAfter tracks the expiration of cert-manager certificates
+	CertManagerCertificateNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "cert_manager_certificate_not_after_timestamp_seconds",
+		Help: "Unix time at which the certificate issued for a cert-manager Certificate expires",
+	}, []string{"cluster", "namespace", "certificate"})
+
+	// CertManagerCertificateStatus exposes the status of cert-manager certificates
+	CertManagerCertificateStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "cert_manager_certificate_status",
+		Help: "Current status of a cert-manager Certificate and the reason it is not ready (always 1)",
+	}, []string{"cluster", "namespace", "certificate", "status", "reason"})
+
+	// IngressProbeSuccess tracks whether the SSL probes of Ingress hosts succeeded
+	IngressProbeSuccess = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "ingress_probe_success",
+		Help: "Whether the SSL probe of an Ingress through its internal or external endpoint succeeded (1) or failed (0)",
+	}, []string{"cluster", "namespace", "ingress", "endpoint"})
+
+	// WebhookCABundleNotAfter tracks the expiration of CAs embedded in webhook and APIService caBundles
+	WebhookCABundleNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "webhook_ca_bundle_not_after_timestamp_seconds",
+		Help: "Unix time at which a CA certificate embedded in a caBundle expires",
+	}, []string{"cluster", "kind", "name", "webhook", "ca_subject", "ca_fingerprint"})
+
+	// WebhookCABundleStatus exposes the status of CAs embedded in caBundles
+	WebhookCABundleStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "webhook_ca_bundle_status",
+		Help: "Current status of a CA certificate embedded in a caBundle (always 1)",
+	}, []string{"cluster", "kind", "name", "webhook", "ca_subject", "ca_fingerprint", "status"})
+
+	// GatewayCertificateNotAfter tracks the expiration of certificates referenced by Gateway listeners
+	GatewayCertificateNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "gateway_certificate_not_after_timestamp_seconds",
+		Help: "Unix time at which a certificate referenced by a Gateway listener expires",
+	}, []string{"cluster", "namespace", "gateway", "listener", "secret"})
+
+	// GatewayCertificateStatus exposes the status of certificates referenced by Gateway listeners
+	GatewayCertificateStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "gateway_certificate_status",
+		Help: "Current status of a certificate referenced by a Gateway listener (always 1)",
+	}, []string{"cluster", "namespace", "gateway", "listener", "secret", "status"})
 
-	// CertificateExpiryDays tracks the days until certificate expiration
-	CertificateExpiryDays = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-		Name: "certificate_expiry_days",
-		Help: "Days until certificate expiration",
-	}, []string{"namespace", "secret_name"})
+	// GatewayProbeSuccess tracks whether the SSL probe of a Gateway address succeeded
+	GatewayProbeSuccess = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "gateway_probe_success",
+		Help: "Whether the SSL probe of a Gateway listener address succeeded (1) or failed (0)",
+	}, []string{"cluster", "namespace", "gateway", "listener", "address"})
+
+	// RouteCertificateNotAfter tracks the expiration of certificates embedded in OpenShift Routes
+	RouteCertificateNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "route_certificate_not_after_timestamp_seconds",
+		Help: "Unix time at which a certificate embedded inline in an OpenShift Route expires",
+	}, []string{"cluster", "namespace", "route", "field"})
+
+	// RouteCertificateStatus exposes the status of certificates embedded in OpenShift Routes
+	RouteCertificateStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "route_certificate_status",
+		Help: "Current status of a certificate embedded inline in an OpenShift Route (always 1)",
+	}, []string{"cluster", "namespace", "route", "field", "status"})
+
+	// ServiceCertificateNotAfter tracks the expiration of chains served by probed Services
+	ServiceCertificateNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "service_certificate_not_after_timestamp_seconds",
+		Help: "Unix time at which the soonest-expiring certificate served by a probed Service expires",
+	}, []string{"cluster", "namespace", "service", "address"})
+
+	// ServiceProbeStatus exposes the status of probed Services
+	ServiceProbeStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "service_probe_status",
+		Help: "Current status of the TLS probe of a Service address (always 1)",
+	}, []string{"cluster", "namespace", "service", "address", "status"})
+
+	// ExternalCertificateNotAfter tracks the expiration of chains served by external endpoints
+	ExternalCertificateNotAfter = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "external_endpoint_certificate_not_after_timestamp_seconds",
+		Help: "Unix time at which the soonest-expiring certificate served by an external endpoint expires",
+	}, []string{"cluster", "target", "sni"})
+
+	// ExternalProbeSuccess tracks whether the TLS probe of an external endpoint succeeded
+	ExternalProbeSuccess = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "external_endpoint_probe_success",
+		Help: "Whether the TLS probe of a configured external endpoint succeeded (1) or failed (0)",
+	}, []string{"cluster", "target", "sni"})
+
+	// ExternalProbeStatus exposes the status of external endpoints
+	ExternalProbeStatus = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "external_endpoint_status",
+		Help: "Current status of a configured external endpoint (always 1)",
+	}, []string{"cluster", "target", "sni", "status"})
+
+	// TLSProbeInfo exposes the protocol parameters negotiated by a TLS probe
+	TLSProbeInfo = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "tls_probe_info",
+		Help: "Negotiated TLS version, cipher suite and ALPN protocol of a probed endpoint (always 1)",
+	}, []string{"cluster", "source", "namespace", "name", "address", "version", "cipher_suite", "alpn"})
+
+	// TLSProbeFindings flags weak TLS configurations found by a probe
+	TLSProbeFindings = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "tls_probe_findings",
+		Help: "Weak TLS configuration found on a probed endpoint (always 1)",
+	}, []string{"cluster", "source", "namespace", "name", "address", "finding"})
+
+	// CertificateRevoked tracks the OCSP/CRL revocation status of certificates
+	CertificateRevoked = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "certificate_revoked",
+		Help: "Whether a certificate has been revoked (1) or confirmed good (0) by OCSP or CRL",
+	}, []string{"cluster", "source", "namespace", "name"})
+
+	// ObjectSilenced flags the objects whose notifications are silenced
+	ObjectSilenced = newGaugeFamily(prometheus.GaugeOpts{
+		Name: "object_silenced",
+		Help: "Whether notifications for an object are silenced by an API silence or the ignore-until annotation (always 1)",
+	}, []string{"cluster", "kind", "namespace", "name", "silence"})
+
+	// HubAgentLastSeen tracks when the hub last accepted a snapshot from an agent
+	HubAgentLastSeen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hub_agent_last_seen_timestamp_seconds",
+		Help: "Unix time at which the hub last accepted a snapshot from an agent",
+	}, []string{"cluster", "agent"})
+
+	// HubAgentUp tracks whether an agent is still reporting to the hub
+	HubAgentUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "hub_agent_up",
+		Help: "Whether an agent reported within HUB_STALE_AFTER (1) or stopped reporting (0)",
+	}, []string{"cluster", "agent"})
 )
 
-func init() {
-	// Register all metrics
-	prometheus.MustRegister(LastCheckTime)
-	prometheus.MustRegister(ErrorCounter)
-	prometheus.MustRegister(CertificateExpiryDays)
+// exported lists every metric exported by KubeCertWatch.
+var exported = []prometheus.Collector{
+	LastCheckTime,
+	ErrorCounter,
+	CheckDuration,
+	CheckedObjects,
+	TLSSecretNotAfter,
+	TLSSecretStatus,
+	CertManagerCertificateNotAfter,
+	CertManagerCertificateStatus,
+	IngressProbeSuccess,
+	WebhookCABundleNotAfter,
+	WebhookCABundleStatus,
+	GatewayCertificateNotAfter,
+	GatewayCertificateStatus,
+	GatewayProbeSuccess,
+	RouteCertificateNotAfter,
+	RouteCertificateStatus,
+	ServiceCertificateNotAfter,
+	ServiceProbeStatus,
+	ExternalCertificateNotAfter,
+	ExternalProbeSuccess,
+	ExternalProbeStatus,
+	TLSProbeInfo,
+	TLSProbeFindings,
+	CertificateRevoked,
+	ObjectSilenced,
+	HubAgentLastSeen,
+	HubAgentUp,
+}
+
+// registry holds the metrics served on /metrics.
+var registry = prometheus.NewRegistry()
+
+// Register registers all metrics. Their names are prefixed with prefix and every series carries
+// constLabels. KubeCertWatch metrics label each series with the cluster it describes; the Go runtime
+// and process metrics are labelled with cluster, the cluster of this instance.
+func Register(prefix, cluster string, constLabels prometheus.Labels) error {
+	prefixed := prometheus.WrapRegistererWithPrefix(prefix, prometheus.WrapRegistererWith(constLabels, registry))
+	for _, collector := range exported {
+		if err := prefixed.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	runtimeLabels := prometheus.Labels{"cluster": cluster}
+	for name, value := range constLabels {
+		runtimeLabels[name] = value
+	}
+	runtime := prometheus.WrapRegistererWith(runtimeLabels, registry)
+	if err := runtime.Register(collectors.NewGoCollector()); err != nil {
+		return err
+	}
+	return runtime.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+}
+
+// Handler serves the registered metrics.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// Gatherer returns the registry behind /metrics, for exporters that forward the same metrics elsewhere.
+func Gatherer() prometheus.Gatherer {
+	return registry
 }
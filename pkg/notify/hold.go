@@ -0,0 +1,260 @@
+// pkg/notify/hold.go
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// HeldConfigMapName is the ConfigMap in the pod namespace that holds the findings held during quiet
+	// windows.
+	HeldConfigMapName = "kubecertwatch-held-notifications"
+	// heldConfigMapKey is the key of the JSON-encoded held findings in the ConfigMap.
+	heldConfigMapKey = "held.json"
+	// heldTimeout bounds the requests that read and write the held findings.
+	heldTimeout = 30 * time.Second
+)
+
+// heldBatch is the findings held for a receiver until a quiet window ends, keyed by findingKey.
+type heldBatch struct {
+	Receiver string             `json:"receiver"`
+	Until    time.Time          `json:"until"`
+	Findings map[string]Finding `json:"findings"`
+}
+
+// heldFinding is a finding to hold for receiver until the quiet window ending at until is over.
+type heldFinding struct {
+	receiver string
+	until    time.Time
+	finding  Finding
+}
+
+var (
+	// heldLock serializes changes to the held findings and guards the client used to store them.
+	heldLock sync.Mutex
+	// held maps a receiver and the end of its quiet window to the findings held for it
+	held          = map[string]*heldBatch{}
+	heldClient    kubernetes.Interface
+	heldNamespace string
+)
+
+// StoreHeld keeps the held findings in a ConfigMap in the local cluster from now on, so that they
+// survive restarts and are delivered by whichever replica leads when the quiet window ends. Without
+// it, held findings are kept in memory only.
+func StoreHeld() error {
+	cluster, err := k8s.LocalCluster()
+	if err != nil {
+		return err
+	}
+
+	heldLock.Lock()
+	heldClient, heldNamespace = cluster.Clientset, k8s.PodNamespace()
+	heldLock.Unlock()
+	return nil
+}
+
+// hold keeps findings until the quiet windows of their receivers are over. A finding held again
+// replaces its earlier version.
+func hold(findings []heldFinding) {
+	updateHeld(func() bool {
+		for _, f := range findings {
+			key := heldKey(f.receiver, f.until)
+			batch, ok := held[key]
+			if !ok {
+				batch = &heldBatch{Receiver: f.receiver, Until: f.until, Findings: map[string]Finding{}}
+				held[key] = batch
+			}
+			batch.Findings[findingKey(f.finding)] = f.finding
+		}
+		return len(findings) > 0
+	})
+}
+
+// dueBatches returns copies of the batches whose quiet window has ended at now, keyed like held.
+func dueBatches(now time.Time) map[string]heldBatch {
+	due := map[string]heldBatch{}
+	updateHeld(func() bool {
+		for key, batch := range held {
+			if !now.Before(batch.Until) {
+				findings := make(map[string]Finding, len(batch.Findings))
+				for k, finding := range batch.Findings {
+					findings[k] = finding
+				}
+				due[key] = heldBatch{Receiver: batch.Receiver, Until: batch.Until, Findings: findings}
+			}
+		}
+		return false
+	})
+	return due
+}
+
+// removeHeld removes the batches with keys from the held findings.
+func removeHeld(keys []string) {
+	updateHeld(func() bool {
+		removed := false
+		for _, key := range keys {
+			if _, ok := held[key]; ok {
+				delete(held, key)
+				removed = true
+			}
+		}
+		return removed
+	})
+}
+
+// heldKey identifies the batch held for receiver until a quiet window ends.
+func heldKey(receiver string, until time.Time) string {
+	return receiver + "@" + until.UTC().Format(time.RFC3339)
+}
+
+// updateHeld applies modify to the held findings; modify reports whether it changed them. When they
+// are stored in the ConfigMap, they are read from it first and written back only when changed, so that
+// a new leader continues with the findings held by the previous one. Storage failures are logged and
+// the change is kept in memory.
+func updateHeld(modify func() bool) {
+	heldLock.Lock()
+	defer heldLock.Unlock()
+	if heldClient == nil {
+		modify()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), heldTimeout)
+	defer cancel()
+	applied := false
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := heldClient.CoreV1().ConfigMaps(heldNamespace).Get(ctx, HeldConfigMapName, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      HeldConfigMapName,
+				Namespace: heldNamespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "kubecertwatch"},
+			}}
+		} else if err != nil {
+			return err
+		}
+
+		stored := map[string]*heldBatch{}
+		if data := configMap.Data[heldConfigMapKey]; data != "" {
+			if err := json.Unmarshal([]byte(data), &stored); err != nil {
+				return err
+			}
+		}
+		held = stored
+		changed := modify()
+		applied = true
+		if !changed {
+			return nil
+		}
+
+		data, err := json.MarshalIndent(held, "", "  ")
+		if err != nil {
+			return err
+		}
+		configMap.Data = map[string]string{heldConfigMapKey: string(data)}
+		if create {
+			_, err = heldClient.CoreV1().ConfigMaps(heldNamespace).Create(ctx, configMap, metav1.CreateOptions{})
+		} else {
+			_, err = heldClient.CoreV1().ConfigMaps(heldNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		log.Warnf("Failed to store held notifications in ConfigMap %s/%s, keeping them in memory: %v", heldNamespace, HeldConfigMapName, err)
+		if !applied {
+			modify()
+		}
+	}
+}
+
+// ReleaseHeld delivers the findings held during quiet windows that have ended, one message per
+// receiver. Held findings are sent with their current status; those that were resolved, muted or
+// silenced in the meantime are dropped. Batches are only removed once their receiver accepted them, so
+// that a failed delivery is retried on the next call.
+func ReleaseHeld() {
+	due := dueBatches(time.Now())
+	if len(due) == 0 {
+		return
+	}
+
+	clusterSet := map[string]bool{}
+	for _, batch := range due {
+		for _, finding := range batch.Findings {
+			clusterSet[finding.Cluster] = true
+		}
+	}
+	clusters := make([]string, 0, len(clusterSet))
+	for cluster := range clusterSet {
+		clusters = append(clusters, cluster)
+	}
+	current := map[string]Finding{}
+	for _, finding := range collect(clusters) {
+		current[findingKey(finding)] = finding
+	}
+
+	batches := map[string][]Finding{}
+	queued := map[string]bool{}
+	for _, batch := range due {
+		for key := range batch.Findings {
+			finding, open := current[key]
+			if !open || queued[batch.Receiver+"/"+key] {
+				continue
+			}
+			queued[batch.Receiver+"/"+key] = true
+			batches[batch.Receiver] = append(batches[batch.Receiver], finding)
+		}
+	}
+	for _, batch := range batches {
+		sortFindings(batch)
+	}
+
+	failed, err := deliver(context.Background(), config.Current().Receivers, batches)
+	if err != nil {
+		log.Errorf("Failed to deliver findings held during quiet windows, retrying later: %v", err)
+		metrics.ErrorCounter.WithLabelValues(config.Current().ClusterName, "notify", "send_error").Inc()
+	}
+	var delivered []string
+	for key, batch := range due {
+		if !failed[batch.Receiver] {
+			delivered = append(delivered, key)
+		}
+	}
+	if len(delivered) > 0 {
+		removeHeld(delivered)
+	}
+}
+
+// HasQuietWindows reports whether route or any of its child routes has quiet windows, that is whether
+// findings can be held at all.
+func HasQuietWindows(route *config.Route) bool {
+	if route == nil {
+		return false
+	}
+	if len(route.QuietWindows) > 0 {
+		return true
+	}
+	for i := range route.Routes {
+		if HasQuietWindows(&route.Routes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// findingKey identifies the result a finding is about.
+func findingKey(finding Finding) string {
+	return finding.Cluster + "/" + finding.Namespace + "/" + finding.Kind + "/" + finding.Name + "/" + finding.Item
+}
@@ -0,0 +1,202 @@
+// pkg/notify/notify.go
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	"github.com/supporttools/KubeCertWatch/pkg/policy"
+	"github.com/supporttools/KubeCertWatch/pkg/reports"
+)
+
+var log = logging.SetupLogging()
+
+// Severities of findings.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// criticalExpiry is how close to expiry a certificate that is expiring soon becomes critical, so that
+// its finding is not held during quiet windows.
+const criticalExpiry = 24 * time.Hour
+
+// Finding is a result that needs attention, as sent to receivers.
+type Finding struct {
+	Cluster   string   `json:"cluster"`
+	Namespace string   `json:"namespace,omitempty"`
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	Item      string   `json:"item,omitempty"`
+	Status    string   `json:"status"`
+	Severity  string   `json:"severity"`
+	Owner     string   `json:"owner,omitempty"`
+	Policy    string   `json:"policy,omitempty"`
+	NotAfter  string   `json:"notAfter,omitempty"`
+	DaysUntil *int     `json:"daysUntil,omitempty"`
+	Details   []string `json:"details,omitempty"`
+}
+
+// labels returns the labels routes match on.
+func (f Finding) labels() map[string]string {
+	return map[string]string{
+		"cluster":   f.Cluster,
+		"namespace": f.Namespace,
+		"owner":     f.Owner,
+		"kind":      f.Kind,
+		"severity":  f.Severity,
+	}
+}
+
+// Notify sends the findings of clusters to their receivers, one message per receiver. Findings that are
+// not critical and fall in a quiet window of their route are held until the window ends. It does
+// nothing when no receivers are configured.
+func Notify(ctx context.Context, clusters []string) error {
+	cfg := config.Current()
+	if len(cfg.Receivers) == 0 || cfg.Route == nil {
+		return nil
+	}
+
+	now := time.Now()
+	batches := map[string][]Finding{}
+	var holding []heldFinding
+	heldCounts := map[string]int{}
+	for _, finding := range collect(clusters) {
+		for _, target := range targetsOf(finding, *cfg.Route) {
+			if finding.Severity != SeverityCritical {
+				if until, quiet := config.QuietUntil(target.windows, now); quiet {
+					holding = append(holding, heldFinding{receiver: target.receiver, until: until, finding: finding})
+					heldCounts[target.receiver]++
+					continue
+				}
+			}
+			batches[target.receiver] = append(batches[target.receiver], finding)
+		}
+	}
+	if len(holding) > 0 {
+		hold(holding)
+	}
+	for receiver, count := range heldCounts {
+		log.Printf("Holding %d findings for receiver %s until its quiet window ends", count, receiver)
+	}
+	_, err := deliver(ctx, cfg.Receivers, batches)
+	return err
+}
+
+// deliver sends each batch to its receiver. It returns the receivers that failed to accept their batch.
+func deliver(ctx context.Context, receivers []config.Receiver, batches map[string][]Finding) (map[string]bool, error) {
+	failed := map[string]bool{}
+	var errs []error
+	for _, receiver := range receivers {
+		batch := batches[receiver.Name]
+		delete(batches, receiver.Name)
+		if len(batch) == 0 {
+			continue
+		}
+		if err := send(ctx, receiver, batch); err != nil {
+			errs = append(errs, fmt.Errorf("receiver %s: %w", receiver.Name, err))
+			failed[receiver.Name] = true
+			continue
+		}
+		log.Printf("Sent %d findings to receiver %s", len(batch), receiver.Name)
+	}
+	for name, batch := range batches {
+		log.Warnf("Dropped %d findings for unknown receiver %s", len(batch), name)
+	}
+	return failed, errors.Join(errs...)
+}
+
+// collect returns the findings of clusters: the warning and failing results that are neither muted nor
+// silenced.
+func collect(clusters []string) []Finding {
+	var findings []Finding
+	for _, cluster := range clusters {
+		for _, result := range reports.Collect(cluster, "") {
+			if result.Muted || result.Silence != "" {
+				continue
+			}
+			severity := SeverityCritical
+			switch result.State() {
+			case reports.StateHealthy:
+				continue
+			case reports.StateWarning:
+				severity = SeverityWarning
+				if expiresWithin(result.NotAfter, criticalExpiry) {
+					severity = SeverityCritical
+				}
+			}
+			findings = append(findings, Finding{
+				Cluster:   cluster,
+				Namespace: result.Namespace(),
+				Kind:      result.Kind,
+				Name:      result.Name,
+				Item:      result.Item,
+				Status:    result.Status,
+				Severity:  severity,
+				Owner:     result.Owner,
+				Policy:    result.Policy,
+				NotAfter:  result.NotAfter,
+				DaysUntil: result.DaysUntil,
+				Details:   result.Findings,
+			})
+		}
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// expiresWithin reports whether the certificate expiring at notAfter, an RFC 3339 time, expires
+// within d.
+func expiresWithin(notAfter string, d time.Duration) bool {
+	expiry, err := time.Parse(time.RFC3339, notAfter)
+	return err == nil && time.Until(expiry) < d
+}
+
+// sortFindings puts critical findings first.
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == SeverityCritical && findings[j].Severity != SeverityCritical
+	})
+}
+
+// targetsOf returns the targets of finding: the receivers named by its policy, otherwise those selected
+// by the routing tree. Receivers named by a policy observe the quiet windows of the matching route that
+// sends to them, or else those of the first route the finding matches.
+func targetsOf(finding Finding, root config.Route) []target {
+	routed := route(root, finding.labels(), target{})
+	if finding.Policy != "" {
+		if receivers := policy.Receivers(finding.Policy); len(receivers) > 0 {
+			targets := make([]target, 0, len(receivers))
+			for _, receiver := range receivers {
+				windows := routed[0].windows
+				for _, t := range routed {
+					if t.receiver == receiver {
+						windows = t.windows
+						break
+					}
+				}
+				targets = append(targets, target{receiver: receiver, windows: windows})
+			}
+			return dedupe(targets)
+		}
+	}
+	return dedupe(routed)
+}
+
+// dedupe removes repeated receivers, keeping the first occurrence.
+func dedupe(targets []target) []target {
+	seen := map[string]bool{}
+	unique := targets[:0]
+	for _, target := range targets {
+		if !seen[target.receiver] {
+			seen[target.receiver] = true
+			unique = append(unique, target)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,58 @@
+// pkg/notify/route.go
+package notify
+
+import (
+	"regexp"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+)
+
+// target is a receiver selected for a finding, with the quiet windows of the route that selected it.
+type target struct {
+	receiver string
+	windows  []config.QuietWindow
+}
+
+// route walks the routing tree below r, which has already matched labels, and returns the targets of
+// the deepest matching routes. parent holds the receiver and quiet windows inherited from the parent
+// route.
+func route(r config.Route, labels map[string]string, parent target) []target {
+	current := parent
+	if r.Receiver != "" {
+		current.receiver = r.Receiver
+	}
+	if len(r.QuietWindows) > 0 {
+		current.windows = r.QuietWindows
+	}
+
+	var targets []target
+	for _, child := range r.Routes {
+		if !matches(child, labels) {
+			continue
+		}
+		targets = append(targets, route(child, labels, current)...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(targets) == 0 {
+		return []target{current}
+	}
+	return targets
+}
+
+// matches reports whether labels satisfy every matcher of r. Regular expressions are anchored.
+func matches(r config.Route, labels map[string]string) bool {
+	for name, value := range r.Match {
+		if labels[name] != value {
+			return false
+		}
+	}
+	for name, pattern := range r.MatchRE {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil || !re.MatchString(labels[name]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+// pkg/notify/send.go
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+)
+
+// maxSlackFindings bounds the number of findings listed in a Slack message.
+const maxSlackFindings = 50
+
+var sendClient = &http.Client{Timeout: 30 * time.Second}
+
+// WebhookMessage is the JSON body posted to webhook receivers.
+type WebhookMessage struct {
+	Receiver    string    `json:"receiver"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Findings    []Finding `json:"findings"`
+}
+
+// send delivers findings to receiver.
+func send(ctx context.Context, receiver config.Receiver, findings []Finding) error {
+	target, err := receiverURL(receiver)
+	if err != nil {
+		return err
+	}
+
+	var body interface{} = WebhookMessage{Receiver: receiver.Name, GeneratedAt: time.Now().UTC(), Findings: findings}
+	if receiver.Type == config.ReceiverSlack {
+		body = map[string]string{"text": slackText(findings)}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		// The URL is a credential, so the error that quotes it is not returned
+		return fmt.Errorf("invalid url")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := sendClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending notification failed: %w", errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("receiver responded with %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// receiverURL returns the URL of receiver, reading it from its URL file when one is set.
+func receiverURL(receiver config.Receiver) (string, error) {
+	if receiver.URLFile == "" {
+		return receiver.URL, nil
+	}
+	data, err := os.ReadFile(receiver.URLFile)
+	if err != nil {
+		return "", err
+	}
+	target := strings.TrimSpace(string(data))
+	if err := config.ValidateReceiverURL(target); err != nil {
+		return "", fmt.Errorf("%s: %v", receiver.URLFile, err)
+	}
+	return target, nil
+}
+
+// slackText formats findings as a Slack message, critical findings first.
+func slackText(findings []Finding) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "*KubeCertWatch*: %d certificate findings", len(findings))
+	for i, finding := range findings {
+		if i == maxSlackFindings {
+			fmt.Fprintf(&text, "\n…and %d more", len(findings)-maxSlackFindings)
+			break
+		}
+		fmt.Fprintf(&text, "\n• [%s] %s", finding.Severity, describe(finding))
+	}
+	return text.String()
+}
+
+// describe returns a one-line description of finding.
+func describe(finding Finding) string {
+	object := finding.Cluster + "/"
+	if finding.Namespace != "" {
+		object += finding.Namespace + "/"
+	}
+	line := fmt.Sprintf("%s %s%s", finding.Kind, object, finding.Name)
+	if finding.Item != "" {
+		line += " (" + finding.Item + ")"
+	}
+	line += ": " + finding.Status
+	if finding.NotAfter != "" {
+		line += ", expires " + finding.NotAfter
+	}
+	if finding.Owner != "" {
+		line += ", owner " + finding.Owner
+	}
+	return line
+}
@@ -63,10 +63,14 @@ func CertManagerStatusPage(w http.ResponseWriter, _ *http.Request) {
 			<input type="text" id="filterInput" class="filter-input" onkeyup="filterTable()" placeholder="Search certificates...">
 			<table id="statusTable" data-sort-order="asc">
 				<tr>
+					<th>Cluster</th>
 					<th>Namespace</th>
 					<th>Certificate</th>
 					<th>Status</th>
 					<th>Renewal Failure</th>
+					<th>Policy</th>
+					<th>Owner</th>
+					<th>Silenced</th>
 				</tr>
 	`)
 
@@ -77,8 +81,12 @@ func CertManagerStatusPage(w http.ResponseWriter, _ *http.Request) {
 				<td>%s</td>
 				<td>%s</td>
 				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
 			</tr>
-		`, status.Namespace, status.Certificate, status.Status, status.RenewalFailure)
+		`, status.Cluster, status.Namespace, status.Certificate, status.Status, status.RenewalFailure, policyLabel(status.Policy, status.Muted), ownerLabel(status.Owner), silenceLabel(status.Silence()))
 	}
 
 	fmt.Fprint(w, `
@@ -0,0 +1,116 @@
+package pages
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/hub"
+)
+
+// FleetStatusPage provides a simple HTML page displaying the agents reporting to the hub
+func FleetStatusPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+
+	statuses := hub.GetAgentStatuses()
+
+	fmt.Fprint(w, `
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<title>Fleet Status</title>
+			<style>
+				table {
+					border-collapse: collapse;
+					width: 100%;
+				}
+				th, td {
+					border: 1px solid #ddd;
+					padding: 8px;
+				}
+				th {
+					cursor: pointer;
+					background-color: #f2f2f2;
+				}
+				.filter-input {
+					margin-bottom: 10px;
+					padding: 5px;
+					width: 300px;
+				}
+			</style>
+			<script>
+				function filterTable() {
+					let filter = document.getElementById("filterInput").value.toUpperCase();
+					let table = document.getElementById("statusTable");
+					let rows = table.getElementsByTagName("tr");
+
+					for (let i = 1; i < rows.length; i++) {
+						let cells = rows[i].getElementsByTagName("td");
+						let match = false;
+						for (let j = 0; j < cells.length; j++) {
+							if (cells[j].innerText.toUpperCase().indexOf(filter) > -1) {
+								match = true;
+								break;
+							}
+						}
+						rows[i].style.display = match ? "" : "none";
+					}
+				}
+
+				function sortTable(columnIndex) {
+					let table = document.getElementById("statusTable");
+					let rows = Array.from(table.rows).slice(1);
+					let ascending = table.getAttribute("data-sort-order") !== "asc";
+					table.setAttribute("data-sort-order", ascending ? "asc" : "desc");
+
+					rows.sort((a, b) => {
+						let cellA = a.cells[columnIndex].innerText.toUpperCase();
+						let cellB = b.cells[columnIndex].innerText.toUpperCase();
+						if (!isNaN(cellA) && !isNaN(cellB)) {
+							return ascending ? cellA - cellB : cellB - cellA;
+						}
+						return ascending
+							? cellA.localeCompare(cellB)
+							: cellB.localeCompare(cellA);
+					});
+
+					rows.forEach(row => table.appendChild(row));
+				}
+			</script>
+		</head>
+		<body>
+			<h1>Fleet Status</h1>
+			<input type="text" id="filterInput" class="filter-input" onkeyup="filterTable()" placeholder="Search for agents...">
+			<table id="statusTable" data-sort-order="asc">
+				<tr>
+					<th onclick="sortTable(0)">Agent</th>
+					<th onclick="sortTable(1)">Address</th>
+					<th onclick="sortTable(2)">Clusters</th>
+					<th onclick="sortTable(3)">Snapshot Time</th>
+					<th onclick="sortTable(4)">Last Seen</th>
+					<th onclick="sortTable(5)">Status</th>
+				</tr>
+	`)
+
+	for _, status := range statuses {
+		fmt.Fprintf(w, `
+			<tr>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+			</tr>
+		`, status.Agent, status.Address, strings.Join(status.Clusters, ", "), status.GeneratedAt.Format(time.RFC3339),
+			status.LastSeen.Format(time.RFC3339), status.Status)
+	}
+
+	fmt.Fprint(w, `
+			</table>
+		</body>
+		</html>
+	`)
+}
@@ -3,6 +3,7 @@ package pages
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/supporttools/KubeCertWatch/pkg/checks"
 )
@@ -63,10 +64,16 @@ func IngressStatusPage(w http.ResponseWriter, _ *http.Request) {
 			<input type="text" id="filterInput" class="filter-input" onkeyup="filterTable()" placeholder="Search for ingress...">
 			<table id="statusTable">
 				<tr>
+					<th>Cluster</th>
 					<th>Namespace</th>
 					<th>Ingress</th>
 					<th>Internal SSL</th>
 					<th>External SSL</th>
+					<th>TLS</th>
+					<th>Findings</th>
+					<th>Policy</th>
+					<th>Owner</th>
+					<th>Silenced</th>
 				</tr>
 	`)
 
@@ -77,8 +84,15 @@ func IngressStatusPage(w http.ResponseWriter, _ *http.Request) {
 				<td>%s</td>
 				<td>%s</td>
 				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
 			</tr>
-		`, status.Namespace, status.IngressName, status.InternalStatus, status.ExternalStatus)
+		`, status.Cluster, status.Namespace, status.IngressName, status.InternalStatus, status.ExternalStatus,
+			ingressTLSLabel(status.Probes), strings.Join(status.TLSFindings(), "; "), policyLabel(status.Policy, status.Muted), ownerLabel(status.Owner), silenceLabel(status.Silence()))
 	}
 
 	fmt.Fprint(w, `
@@ -87,3 +101,20 @@ func IngressStatusPage(w http.ResponseWriter, _ *http.Request) {
 		</html>
 	`)
 }
+
+// ingressTLSLabel describes the TLS version, cipher suite and ALPN protocol negotiated with each probed
+// address of an Ingress.
+func ingressTLSLabel(probes []checks.ProbeStatus) string {
+	var parts []string
+	for _, probe := range probes {
+		if probe.TLSVersion == "" {
+			continue
+		}
+		part := fmt.Sprintf("%s: %s %s", probe.Address, probe.TLSVersion, probe.CipherSuite)
+		if probe.ALPN != "" {
+			part += " " + probe.ALPN
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "<br>")
+}
@@ -0,0 +1,127 @@
+package pages
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+)
+
+// RouteStatusPage provides a simple HTML page displaying the status of OpenShift Route certificates
+func RouteStatusPage(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+
+	statuses := checks.GetRouteStatuses()
+
+	fmt.Fprint(w, `
+		<!DOCTYPE html>
+		<html>
+		<head>
+			<title>Route Status</title>
+			<style>
+				table {
+					border-collapse: collapse;
+					width: 100%;
+				}
+				th, td {
+					border: 1px solid #ddd;
+					padding: 8px;
+				}
+				th {
+					cursor: pointer;
+					background-color: #f2f2f2;
+				}
+				.filter-input {
+					margin-bottom: 10px;
+					padding: 5px;
+					width: 300px;
+				}
+			</style>
+			<script>
+				function filterTable() {
+					let filter = document.getElementById("filterInput").value.toUpperCase();
+					let table = document.getElementById("statusTable");
+					let rows = table.getElementsByTagName("tr");
+
+					for (let i = 1; i < rows.length; i++) {
+						let cells = rows[i].getElementsByTagName("td");
+						let match = false;
+						for (let j = 0; j < cells.length; j++) {
+							if (cells[j].innerText.toUpperCase().indexOf(filter) > -1) {
+								match = true;
+								break;
+							}
+						}
+						rows[i].style.display = match ? "" : "none";
+					}
+				}
+
+				function sortTable(columnIndex) {
+					let table = document.getElementById("statusTable");
+					let rows = Array.from(table.rows).slice(1);
+					let ascending = table.getAttribute("data-sort-order") !== "asc";
+					table.setAttribute("data-sort-order", ascending ? "asc" : "desc");
+
+					rows.sort((a, b) => {
+						let cellA = a.cells[columnIndex].innerText.toUpperCase();
+						let cellB = b.cells[columnIndex].innerText.toUpperCase();
+						if (!isNaN(cellA) && !isNaN(cellB)) {
+							return ascending ? cellA - cellB : cellB - cellA;
+						}
+						return ascending
+							? cellA.localeCompare(cellB)
+							: cellB.localeCompare(cellA);
+					});
+
+					rows.forEach(row => table.appendChild(row));
+				}
+			</script>
+		</head>
+		<body>
+			<h1>OpenShift Route Status</h1>
+			<input type="text" id="filterInput" class="filter-input" onkeyup="filterTable()" placeholder="Search for routes...">
+			<table id="statusTable" data-sort-order="asc">
+				<tr>
+					<th onclick="sortTable(0)">Cluster</th>
+					<th onclick="sortTable(1)">Namespace</th>
+					<th onclick="sortTable(2)">Route</th>
+					<th onclick="sortTable(3)">Host</th>
+					<th onclick="sortTable(4)">Field</th>
+					<th onclick="sortTable(5)">Subject</th>
+					<th onclick="sortTable(6)">Expiration Date</th>
+					<th onclick="sortTable(7)">Days Until</th>
+					<th onclick="sortTable(8)">Hostname Covered</th>
+					<th onclick="sortTable(9)">Status</th>
+					<th onclick="sortTable(10)">Policy</th>
+					<th onclick="sortTable(11)">Owner</th>
+					<th onclick="sortTable(12)">Silenced</th>
+				</tr>
+	`)
+
+	for _, status := range statuses {
+		fmt.Fprintf(w, `
+			<tr>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%d</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+			</tr>
+		`, status.Cluster, status.Namespace, status.Route, status.Host, status.Field, status.Subject, status.ExpirationDate, status.DaysUntil, status.HostnameCovered, status.Status, policyLabel(status.Policy, status.Muted), ownerLabel(status.Owner), silenceLabel(status.Silence()))
+	}
+
+	fmt.Fprint(w, `
+			</table>
+		</body>
+		</html>
+	`)
+}
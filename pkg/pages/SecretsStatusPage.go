@@ -83,11 +83,16 @@ func SecretsStatusPage(w http.ResponseWriter, _ *http.Request) {
 			<input type="text" id="filterInput" class="filter-input" onkeyup="filterTable()" placeholder="Search for secrets...">
 			<table id="statusTable" data-sort-order="asc">
 				<tr>
-					<th onclick="sortTable(0)">Namespace</th>
-					<th onclick="sortTable(1)">Secret Name</th>
-					<th onclick="sortTable(2)">Expiration Date</th>
-					<th onclick="sortTable(3)">Days Until</th>
-					<th onclick="sortTable(4)">Status</th>
+					<th onclick="sortTable(0)">Cluster</th>
+					<th onclick="sortTable(1)">Namespace</th>
+					<th onclick="sortTable(2)">Secret Name</th>
+					<th onclick="sortTable(3)">Expiration Date</th>
+					<th onclick="sortTable(4)">Days Until</th>
+					<th onclick="sortTable(5)">Status</th>
+					<th onclick="sortTable(6)">Revocation</th>
+					<th onclick="sortTable(7)">Policy</th>
+					<th onclick="sortTable(8)">Owner</th>
+					<th onclick="sortTable(9)">Silenced</th>
 				</tr>
 	`)
 
@@ -97,10 +102,15 @@ func SecretsStatusPage(w http.ResponseWriter, _ *http.Request) {
 				<td>%s</td>
 				<td>%s</td>
 				<td>%s</td>
+				<td>%s</td>
 				<td>%d</td>
 				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
+				<td>%s</td>
 			</tr>
-		`, status.Namespace, status.SecretName, status.ExpirationDate, status.DaysUntil, status.Status)
+		`, status.Cluster, status.Namespace, status.SecretName, status.ExpirationDate, status.DaysUntil, status.Status, status.Revocation, policyLabel(status.Policy, status.Muted), ownerLabel(status.Owner), silenceLabel(status.Silence()))
 	}
 
 	fmt.Fprint(w, `
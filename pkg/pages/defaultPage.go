@@ -18,18 +18,31 @@ func DefaultPage(w http.ResponseWriter, _ *http.Request) {
 				<li><a href="/metrics">Metrics</a></li>
 				<li><a href="/healthz">Health Check</a></li>
 				<li><a href="/version">Version</a></li>
+				<li><a href="/prometheus/rules">Recommended Prometheus rules</a></li>
 			</ul>
 			<h2>Checks</h2>
 			<ul>
 				<li><a href="/check/secrets">Check for expiring certs</a></li>
 				<li><a href="/check/cert-manager">Check cert-manager certificates</a></li>
 				<li><a href="/check/ingress">Check Ingress SSL status</a></li>
+				<li><a href="/check/webhooks">Check webhook and APIService caBundles</a></li>
+				<li><a href="/check/gateways">Check Gateway listener certificates</a></li>
+				<li><a href="/check/routes">Check OpenShift Route certificates</a></li>
+				<li><a href="/check/services">Probe annotated Service TLS endpoints</a></li>
+				<li><a href="/check/external">Probe external TLS endpoints</a></li>
+				<li><a href="/api/v1/runs">View recent check runs</a></li>
 			</ul>
 			<h2>Status Pages</h2>
 			<ul>
 				<li><a href="/status/secrets">View TLS secret status</a></li>
 				<li><a href="/status/cert-manager">View cert-manager certificate status</a></li>
 				<li><a href="/status/ingress">View Ingress SSL status</a></li>
+				<li><a href="/status/webhooks">View webhook caBundle status</a></li>
+				<li><a href="/status/gateways">View Gateway listener status</a></li>
+				<li><a href="/status/routes">View OpenShift Route status</a></li>
+				<li><a href="/status/services">View Service TLS probe status</a></li>
+				<li><a href="/status/external">View external endpoint status</a></li>
+				<li><a href="/status/fleet">View agents reporting to the hub</a></li>
 			</ul>
 		</body>
 		</html>
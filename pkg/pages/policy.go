@@ -0,0 +1,34 @@
+package pages
+
+import (
+	"html"
+
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+)
+
+// policyLabel renders the policy column of a status row.
+func policyLabel(policy string, muted bool) string {
+	if policy == "" {
+		policy = "-"
+	}
+	if muted {
+		policy += " (muted)"
+	}
+	return policy
+}
+
+// ownerLabel renders the owner column of a status row.
+func ownerLabel(owner string) string {
+	if owner == "" {
+		return "-"
+	}
+	return owner
+}
+
+// silenceLabel renders the silenced column of a status row.
+func silenceLabel(silence silences.Silence, silenced bool) string {
+	if !silenced {
+		return "-"
+	}
+	return html.EscapeString(silence.Describe())
+}
@@ -0,0 +1,183 @@
+// pkg/policy/policy.go
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	log = logging.SetupLogging()
+
+	// CertWatchPolicyGVR is the GroupVersionResource of namespaced CertWatchPolicies.
+	CertWatchPolicyGVR = schema.GroupVersionResource{Group: "kubecertwatch.io", Version: "v1alpha1", Resource: "certwatchpolicies"}
+	// ClusterCertWatchPolicyGVR is the GroupVersionResource of cluster-scoped ClusterCertWatchPolicies.
+	ClusterCertWatchPolicyGVR = schema.GroupVersionResource{Group: "kubecertwatch.io", Version: "v1alpha1", Resource: "clustercertwatchpolicies"}
+
+	policyLock sync.RWMutex
+	policies   []compiled // namespaced policies first, then cluster policies, each sorted by name
+)
+
+// Spec is the spec of a CertWatchPolicy or ClusterCertWatchPolicy.
+type Spec struct {
+	// Selector restricts the policy to objects with matching labels. An empty selector matches every object.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// WarningDays replaces EXPIRY_WARNING_DAYS for the selected objects.
+	WarningDays int `json:"warningDays,omitempty"`
+	// CriticalDays is the number of days before expiration at which certificates become critical.
+	CriticalDays int `json:"criticalDays,omitempty"`
+	// Receivers names the notification receivers of the selected objects.
+	Receivers []string `json:"receivers,omitempty"`
+	// Mute lists objects that are still checked but not alerted on.
+	Mute []ObjectRef `json:"mute,omitempty"`
+}
+
+// ObjectRef identifies a monitored object in the mute list of a policy.
+type ObjectRef struct {
+	Kind string `json:"kind"`
+	// Namespace is only used by ClusterCertWatchPolicies; CertWatchPolicies mute objects in their own namespace.
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Object is a monitored object that a policy is resolved for. Cluster-scoped objects and external
+// endpoints have no namespace and are only governed by ClusterCertWatchPolicies.
+type Object struct {
+	Kind      string
+	Namespace string
+	Name      string
+	Labels    map[string]string
+}
+
+// Resolved is the outcome of resolving the policy of an object.
+type Resolved struct {
+	// Name is namespace/name for a CertWatchPolicy, the name of a ClusterCertWatchPolicy, or empty
+	// when no policy applies and the global configuration is used.
+	Name         string
+	WarningDays  int
+	CriticalDays int
+	Receivers    []string
+	Muted        bool
+}
+
+// compiled is a validated policy.
+type compiled struct {
+	name      string
+	namespace string
+	selector  labels.Selector
+	spec      Spec
+}
+
+// compile validates spec and prepares it for matching. namespace is empty for cluster policies.
+func compile(namespace, name string, spec Spec) (compiled, error) {
+	p := compiled{name: name, namespace: namespace, spec: spec}
+	if namespace != "" {
+		p.name = namespace + "/" + name
+	}
+
+	if spec.WarningDays < 0 || spec.CriticalDays < 0 {
+		return p, fmt.Errorf("warningDays and criticalDays must not be negative")
+	}
+	if spec.WarningDays > 0 && spec.CriticalDays > spec.WarningDays {
+		return p, fmt.Errorf("criticalDays (%d) must not exceed warningDays (%d)", spec.CriticalDays, spec.WarningDays)
+	}
+	if warningDays := config.Current().ExpiryWarningDays; spec.WarningDays == 0 && spec.CriticalDays > warningDays {
+		return p, fmt.Errorf("criticalDays (%d) must not exceed the global EXPIRY_WARNING_DAYS (%d) unless warningDays is set", spec.CriticalDays, warningDays)
+	}
+	for _, ref := range spec.Mute {
+		if ref.Kind == "" || ref.Name == "" {
+			return p, fmt.Errorf("mute entries need a kind and a name")
+		}
+	}
+
+	selector := labels.Everything()
+	if spec.Selector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(spec.Selector); err != nil {
+			return p, fmt.Errorf("invalid selector: %w", err)
+		}
+	}
+	p.selector = selector
+	return p, nil
+}
+
+// matches reports whether the policy governs obj.
+func (p compiled) matches(obj Object) bool {
+	if p.namespace != "" && p.namespace != obj.Namespace {
+		return false
+	}
+	return p.selector.Matches(labels.Set(obj.Labels))
+}
+
+// mutes reports whether obj is listed in the mute list of the policy.
+func (p compiled) mutes(obj Object) bool {
+	for _, ref := range p.spec.Mute {
+		namespace := ref.Namespace
+		if p.namespace != "" {
+			namespace = p.namespace
+		}
+		if strings.EqualFold(ref.Kind, obj.Kind) && ref.Name == obj.Name && namespace == obj.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the policy that governs obj: the first matching CertWatchPolicy in its namespace,
+// otherwise the first matching ClusterCertWatchPolicy, in order of name. Settings a policy leaves
+// unset fall back to the global configuration.
+func Resolve(obj Object) Resolved {
+	resolved := Resolved{WarningDays: config.Current().ExpiryWarningDays}
+
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	for _, p := range policies {
+		if !p.matches(obj) {
+			continue
+		}
+		resolved.Name = p.name
+		if p.spec.WarningDays > 0 {
+			resolved.WarningDays = p.spec.WarningDays
+		}
+		// The global warning threshold may have been lowered below criticalDays by a reload
+		resolved.CriticalDays = min(p.spec.CriticalDays, resolved.WarningDays)
+		resolved.Receivers = append([]string(nil), p.spec.Receivers...)
+		resolved.Muted = p.mutes(obj)
+		break
+	}
+	return resolved
+}
+
+// Receivers returns the notification receivers of the active policy with the given resolved name.
+func Receivers(name string) []string {
+	policyLock.RLock()
+	defer policyLock.RUnlock()
+	for _, p := range policies {
+		if p.name == name {
+			return append([]string(nil), p.spec.Receivers...)
+		}
+	}
+	return nil
+}
+
+// setPolicies replaces the active policies.
+func setPolicies(updated []compiled) {
+	sort.SliceStable(updated, func(i, j int) bool {
+		if (updated[i].namespace == "") != (updated[j].namespace == "") {
+			return updated[i].namespace != ""
+		}
+		return updated[i].name < updated[j].name
+	})
+
+	policyLock.Lock()
+	defer policyLock.Unlock()
+	policies = updated
+}
@@ -0,0 +1,136 @@
+// pkg/policy/watch.go
+package policy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// syncTimeout bounds the initial load of the policies.
+const syncTimeout = 30 * time.Second
+
+// loadLock serializes loads, so that a load listing older store contents never replaces a newer one.
+var loadLock sync.Mutex
+
+// Watch loads the CertWatchPolicies and ClusterCertWatchPolicies of the local cluster and keeps them up
+// to date until ctx is done. The policies apply to the objects of every monitored cluster. Without the
+// CRDs, no policy applies and every object is evaluated with the global configuration.
+func Watch(ctx context.Context) error {
+	cluster, err := k8s.LocalCluster()
+	if err != nil {
+		return err
+	}
+
+	installed, err := crdsInstalled(cluster)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		log.Println("CertWatchPolicy CRDs are not installed. Using the global configuration for every object.")
+		return nil
+	}
+
+	dynamicClient, err := cluster.DynamicClient()
+	if err != nil {
+		return err
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, 0)
+	namespaced := factory.ForResource(CertWatchPolicyGVR).Informer()
+	clusterScoped := factory.ForResource(ClusterCertWatchPolicyGVR).Informer()
+
+	reload := func() {
+		load(namespaced.GetStore(), clusterScoped.GetStore())
+	}
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { reload() },
+		UpdateFunc: func(interface{}, interface{}) { reload() },
+		DeleteFunc: func(interface{}) { reload() },
+	}
+	for _, informer := range []cache.SharedIndexInformer{namespaced, clusterScoped} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return err
+		}
+	}
+	factory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), namespaced.HasSynced, clusterScoped.HasSynced) {
+		return errors.New("timed out loading CertWatchPolicies")
+	}
+	count := load(namespaced.GetStore(), clusterScoped.GetStore())
+	log.Printf("Loaded %d CertWatchPolicies and ClusterCertWatchPolicies.", count)
+	return nil
+}
+
+// load compiles the policies held by the informer stores and activates them. Invalid policies are
+// logged and ignored. It returns the number of active policies.
+func load(stores ...cache.Store) int {
+	loadLock.Lock()
+	defer loadLock.Unlock()
+
+	var updated []compiled
+	for _, store := range stores {
+		for _, item := range store.List() {
+			obj, ok := item.(*unstructured.Unstructured)
+			if !ok {
+				continue
+			}
+			p, err := decode(obj)
+			if err != nil {
+				log.Warnf("Ignoring %s %s: %v", obj.GetKind(), displayName(obj), err)
+				continue
+			}
+			updated = append(updated, p)
+		}
+	}
+	setPolicies(updated)
+	log.Debugf("Activated %d policies", len(updated))
+	return len(updated)
+}
+
+// decode converts a policy resource into a compiled policy.
+func decode(obj *unstructured.Unstructured) (compiled, error) {
+	var spec Spec
+	content, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return compiled{}, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, &spec); err != nil {
+		return compiled{}, err
+	}
+	return compile(obj.GetNamespace(), obj.GetName(), spec)
+}
+
+// displayName returns namespace/name, or the name of a cluster-scoped object.
+func displayName(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// crdsInstalled reports whether the API server serves both policy resources.
+func crdsInstalled(cluster k8s.Cluster) (bool, error) {
+	resources, err := cluster.Clientset.Discovery().ServerResourcesForGroupVersion(CertWatchPolicyGVR.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	found := map[string]bool{}
+	for _, resource := range resources.APIResources {
+		found[resource.Name] = true
+	}
+	return found[CertWatchPolicyGVR.Resource] && found[ClusterCertWatchPolicyGVR.Resource], nil
+}
@@ -0,0 +1,289 @@
+// pkg/reports/reports.go
+package reports
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/checks"
+	"github.com/supporttools/KubeCertWatch/pkg/revocation"
+	"github.com/supporttools/KubeCertWatch/pkg/silences"
+)
+
+// Summary states of a report.
+const (
+	StateHealthy = "Healthy"
+	StateWarning = "Warning"
+	StateFailing = "Failing"
+)
+
+// namespaceReportName is the name of the report of a namespace in namespace mode.
+const namespaceReportName = "kubecertwatch"
+
+// invalidNameChars matches the characters that are not allowed in resource names.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// Status is the status of a CertificateReport.
+type Status struct {
+	Conditions []Condition `json:"conditions"`
+	Summary    Summary     `json:"summary"`
+	Results    []Result    `json:"results"`
+}
+
+// Condition is a standard Kubernetes condition, so that kstatus-based tools such as Flux can read the
+// health of a report.
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason"`
+	Message            string `json:"message"`
+	LastTransitionTime string `json:"lastTransitionTime"`
+}
+
+// Summary counts the results of a report by state. Muted results are only counted in Muted.
+type Summary struct {
+	State      string `json:"state"`
+	Total      int    `json:"total"`
+	Healthy    int    `json:"healthy"`
+	Warning    int    `json:"warning"`
+	Failing    int    `json:"failing"`
+	Muted      int    `json:"muted"`
+	NextExpiry string `json:"nextExpiry,omitempty"`
+}
+
+// Result is one certificate, caBundle CA or probed endpoint of a monitored object.
+type Result struct {
+	Source     string   `json:"source"`
+	Kind       string   `json:"kind"`
+	Name       string   `json:"name"`
+	Item       string   `json:"item,omitempty"`
+	Status     string   `json:"status"`
+	NotAfter   string   `json:"notAfter,omitempty"`
+	DaysUntil  *int     `json:"daysUntil,omitempty"`
+	Subject    string   `json:"subject,omitempty"`
+	Issuer     string   `json:"issuer,omitempty"`
+	Chain      string   `json:"chain,omitempty"`
+	Findings   []string `json:"findings,omitempty"`
+	Revocation string   `json:"revocation,omitempty"`
+	Owner      string   `json:"owner,omitempty"`
+	Policy     string   `json:"policy,omitempty"`
+	Muted      bool     `json:"muted,omitempty"`
+	Silence    string   `json:"silence,omitempty"`
+
+	namespace string
+	notAfter  time.Time
+}
+
+// newResult creates a result for a status record of a monitored object.
+func newResult(source, kind, namespace, name, item, status string, notAfter time.Time, daysUntil int, owner, policy string, muted bool) Result {
+	result := Result{Source: source, Kind: kind, Name: name, Item: item, Status: status, Owner: owner, Policy: policy, Muted: muted, namespace: namespace, notAfter: notAfter}
+	if !notAfter.IsZero() {
+		result.NotAfter = notAfter.UTC().Format(time.RFC3339)
+		result.DaysUntil = &daysUntil
+	}
+	return result
+}
+
+// Collect converts the published statuses of cluster into results. Cluster-scoped objects and external
+// endpoints are assigned to defaultNamespace.
+func Collect(cluster, defaultNamespace string) []Result {
+	snapshot := checks.TakeSnapshot([]string{cluster})
+	var results []Result
+
+	for _, s := range snapshot.Secrets {
+		result := newResult("secret", "Secret", s.Namespace, s.SecretName, "", s.Status, s.NotAfter, s.DaysUntil, s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Subject, result.Issuer, result.Chain = s.Subject, s.Issuer, s.Chain
+		result.Revocation = revocationResult(s.Revocation)
+		results = append(results, result)
+	}
+	for _, s := range snapshot.CertManager {
+		result := newResult("cert-manager", "Certificate", s.Namespace, s.Certificate, "", s.Status, s.NotAfter, int(time.Until(s.NotAfter).Hours()/24), s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Subject, result.Issuer, result.Chain = s.Subject, s.Issuer, s.Chain
+		if s.RenewalFailure != "" {
+			result.Findings = []string{s.RenewalFailure}
+		}
+		results = append(results, result)
+	}
+	for _, s := range snapshot.Ingresses {
+		result := newResult("ingress", "Ingress", s.Namespace, s.IngressName, "", s.State(), time.Time{}, 0, s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Findings = append([]string{"internal: " + s.InternalStatus, "external: " + s.ExternalStatus}, s.TLSFindings()...)
+		results = append(results, result)
+	}
+	for _, s := range snapshot.Webhooks {
+		result := newResult("webhook", s.Kind, defaultNamespace, s.Name, s.Webhook, s.Status, s.NotAfter, s.DaysUntil, s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Subject = s.CASubject
+		if s.ServiceStatus != "not probed" {
+			result.Findings = []string{"service: " + s.ServiceStatus}
+		}
+		results = append(results, result)
+	}
+	for _, s := range snapshot.Gateways {
+		result := newResult("gateway", "Gateway", s.Namespace, s.Gateway, s.Listener+" "+s.SecretRef, s.Status, s.NotAfter, s.DaysUntil, s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Subject, result.Issuer, result.Chain = s.Subject, s.Issuer, s.Chain
+		if s.HostnameCovered == "no" {
+			result.Findings = append(result.Findings, "certificate does not cover "+s.Hostname)
+		}
+		if s.ProbeStatus != "not probed" {
+			result.Findings = append(result.Findings, "probe: "+s.ProbeStatus)
+		}
+		results = append(results, result)
+	}
+	for _, s := range snapshot.Routes {
+		result := newResult("route", "Route", s.Namespace, s.Route, s.Field, s.Status, s.NotAfter, s.DaysUntil, s.Owner, s.Policy, s.Muted)
+		result.Silence = silenceResult(s.Silence())
+		result.Subject, result.Issuer, result.Chain = s.Subject, s.Issuer, s.Chain
+		if s.HostnameCovered == "no" {
+			result.Findings = []string{"certificate does not cover " + s.Host}
+		}
+		results = append(results, result)
+	}
+	for _, s := range snapshot.Services {
+		results = append(results, probeResult("service", "Service", s.Namespace, s.Name, s))
+	}
+	for _, s := range snapshot.External {
+		results = append(results, probeResult("external", "External", defaultNamespace, s.Address, s))
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if a.Item != b.Item {
+			return a.Item < b.Item
+		}
+		return a.Source < b.Source
+	})
+	return results
+}
+
+// probeResult converts the status of a probed endpoint.
+func probeResult(source, kind, namespace, name string, s checks.ProbeStatus) Result {
+	item := ""
+	if source == "service" {
+		item = s.Address
+	}
+	result := newResult(source, kind, namespace, name, item, s.Status, s.NotAfter, s.DaysUntil, s.Owner, s.Policy, s.Muted)
+	result.Silence = silenceResult(s.Silence())
+	result.Issuer = s.Issuer
+	result.Chain = s.Chain
+	result.Findings = append([]string(nil), s.Findings...)
+	result.Revocation = revocationResult(s.Revocation)
+	return result
+}
+
+// silenceResult describes the silence of a result, if any.
+func silenceResult(silence silences.Silence, silenced bool) string {
+	if !silenced {
+		return ""
+	}
+	return silence.Describe()
+}
+
+// Namespace returns the namespace the result is assigned to.
+func (r Result) Namespace() string {
+	return r.namespace
+}
+
+// revocationResult omits revocation statuses that were never checked.
+func revocationResult(status string) string {
+	if status == revocation.StatusNotChecked {
+		return ""
+	}
+	return status
+}
+
+// State classifies the status of the result as healthy, warning or failing.
+func (r Result) State() string {
+	switch r.Status {
+	case "valid", "unknown":
+		return StateHealthy
+	case "expiring soon", "not ready":
+		return StateWarning
+	}
+	return StateFailing
+}
+
+// group assigns results to reports, keyed by namespace and report name: one report per object in
+// object mode, or one per namespace in namespace mode.
+func group(results []Result, mode string) map[[2]string][]Result {
+	reports := map[[2]string][]Result{}
+	for _, result := range results {
+		name := namespaceReportName
+		if mode == "object" {
+			name = reportName(result.Kind, result.Name)
+		}
+		key := [2]string{result.namespace, name}
+		reports[key] = append(reports[key], result)
+	}
+	return reports
+}
+
+// reportName returns the name of the report of an object. Names that have to be altered to be valid
+// resource names get a hash suffix, so that they stay unique.
+func reportName(kind, name string) string {
+	original := strings.ToLower(kind + "-" + name)
+	sanitized := strings.Trim(invalidNameChars.ReplaceAllString(original, "-"), ".-")
+	if sanitized == original && len(sanitized) <= 253 {
+		return sanitized
+	}
+	if len(sanitized) > 200 {
+		sanitized = sanitized[:200]
+	}
+	sum := sha256.Sum256([]byte(kind + "/" + name))
+	return fmt.Sprintf("%s-%x", sanitized, sum[:4])
+}
+
+// summarize builds the status of a report from its results.
+func summarize(results []Result) Status {
+	summary := Summary{State: StateHealthy, Total: len(results)}
+	var nextExpiry time.Time
+	for _, result := range results {
+		if result.Muted {
+			summary.Muted++
+			continue
+		}
+		switch result.State() {
+		case StateHealthy:
+			summary.Healthy++
+		case StateWarning:
+			summary.Warning++
+		case StateFailing:
+			summary.Failing++
+		}
+		if !result.notAfter.IsZero() && (nextExpiry.IsZero() || result.notAfter.Before(nextExpiry)) {
+			nextExpiry = result.notAfter
+		}
+	}
+	if !nextExpiry.IsZero() {
+		summary.NextExpiry = nextExpiry.UTC().Format(time.RFC3339)
+	}
+
+	ready := Condition{Type: "Ready", Status: "True", Reason: StateHealthy, Message: "No certificate is failing or expiring soon"}
+	switch {
+	case summary.Failing > 0:
+		summary.State = StateFailing
+		ready.Status, ready.Reason = "False", StateFailing
+		ready.Message = fmt.Sprintf("%d of %d results are failing", summary.Failing, summary.Total)
+	case summary.Warning > 0:
+		summary.State = StateWarning
+		ready.Reason = StateWarning
+		ready.Message = fmt.Sprintf("%d of %d results need attention", summary.Warning, summary.Total)
+	}
+	return Status{Conditions: []Condition{ready}, Summary: summary, Results: results}
+}
@@ -0,0 +1,174 @@
+// pkg/reports/sync.go
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	log = logging.SetupLogging()
+
+	// CertificateReportGVR is the GroupVersionResource of CertificateReports.
+	CertificateReportGVR = schema.GroupVersionResource{Group: "kubecertwatch.io", Version: "v1alpha1", Resource: "certificatereports"}
+)
+
+// managedSelector selects the CertificateReports written by KubeCertWatch.
+const managedSelector = "app.kubernetes.io/managed-by=kubecertwatch"
+
+// Sync writes the published results of cluster as CertificateReports in that cluster and deletes the
+// reports of objects that are no longer monitored. It does nothing when the CRD is not installed.
+func Sync(ctx context.Context, cluster k8s.Cluster) error {
+	installed, err := crdInstalled(cluster)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		log.Printf("CertificateReport CRD is not installed in cluster %s. Skipping reports.", cluster.Name)
+		return nil
+	}
+
+	namespace := config.Current().ReportsNamespace
+	if namespace == "" {
+		namespace = k8s.PodNamespace()
+	}
+	desired := group(Collect(cluster.Name, namespace), config.Current().ReportsMode)
+
+	dynamicClient, err := cluster.DynamicClient()
+	if err != nil {
+		return err
+	}
+	client := dynamicClient.Resource(CertificateReportGVR)
+	existing, err := client.List(ctx, metav1.ListOptions{LabelSelector: managedSelector})
+	if err != nil {
+		return fmt.Errorf("listing CertificateReports: %w", err)
+	}
+
+	var errs []error
+	current := map[[2]string]bool{}
+	for i := range existing.Items {
+		report := &existing.Items[i]
+		key := [2]string{report.GetNamespace(), report.GetName()}
+		current[key] = true
+		results, ok := desired[key]
+		if !ok {
+			if err := client.Namespace(key[0]).Delete(ctx, key[1], metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("deleting CertificateReport %s/%s: %w", key[0], key[1], err))
+				continue
+			}
+			log.Debugf("Deleted CertificateReport %s/%s", key[0], key[1])
+			continue
+		}
+
+		status, changed, err := updatedStatus(report, summarize(results))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("encoding CertificateReport %s/%s: %w", key[0], key[1], err))
+			continue
+		}
+		if !changed {
+			continue
+		}
+		report.Object["status"] = status
+		if _, err := client.Namespace(key[0]).Update(ctx, report, metav1.UpdateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("updating CertificateReport %s/%s: %w", key[0], key[1], err))
+		}
+	}
+
+	for key, results := range desired {
+		if current[key] {
+			continue
+		}
+		report := newReport(key[0], key[1])
+		status, _, err := updatedStatus(report, summarize(results))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("encoding CertificateReport %s/%s: %w", key[0], key[1], err))
+			continue
+		}
+		report.Object["status"] = status
+		if _, err := client.Namespace(key[0]).Create(ctx, report, metav1.CreateOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("creating CertificateReport %s/%s: %w", key[0], key[1], err))
+			continue
+		}
+		log.Debugf("Created CertificateReport %s/%s", key[0], key[1])
+	}
+	return errors.Join(errs...)
+}
+
+// newReport returns an empty CertificateReport managed by KubeCertWatch.
+func newReport(namespace, name string) *unstructured.Unstructured {
+	report := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	report.SetAPIVersion(CertificateReportGVR.GroupVersion().String())
+	report.SetKind("CertificateReport")
+	report.SetNamespace(namespace)
+	report.SetName(name)
+	report.SetLabels(map[string]string{"app.kubernetes.io/managed-by": "kubecertwatch"})
+	return report
+}
+
+// updatedStatus encodes status for report, keeping the transition time of the Ready condition while its
+// status is unchanged. It also reports whether the encoded status differs from the current one.
+func updatedStatus(report *unstructured.Unstructured, status Status) (map[string]interface{}, bool, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	previous, _, _ := unstructured.NestedSlice(report.Object, "status", "conditions")
+	for i := range status.Conditions {
+		status.Conditions[i].LastTransitionTime = now
+		for _, item := range previous {
+			condition, ok := item.(map[string]interface{})
+			if !ok || condition["type"] != status.Conditions[i].Type || condition["status"] != status.Conditions[i].Status {
+				continue
+			}
+			if transition, ok := condition["lastTransitionTime"].(string); ok {
+				status.Conditions[i].LastTransitionTime = transition
+			}
+		}
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, false, err
+	}
+	var encoded map[string]interface{}
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, false, err
+	}
+
+	// Compare both statuses as maps, so that field order does not matter
+	desired, err := json.Marshal(encoded)
+	if err != nil {
+		return nil, false, err
+	}
+	current, err := json.Marshal(report.Object["status"])
+	if err != nil {
+		return nil, false, err
+	}
+	return encoded, !bytes.Equal(desired, current), nil
+}
+
+// crdInstalled reports whether the API server of cluster serves CertificateReports.
+func crdInstalled(cluster k8s.Cluster) (bool, error) {
+	resources, err := cluster.Clientset.Discovery().ServerResourcesForGroupVersion(CertificateReportGVR.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == CertificateReportGVR.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,268 @@
+// pkg/revocation/revocation.go
+package revocation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	"golang.org/x/crypto/ocsp"
+)
+
+// Revocation statuses reported by the Checker.
+const (
+	StatusGood       = "good"
+	StatusRevoked    = "revoked"
+	StatusUnknown    = "unknown"
+	StatusNoStaple   = "no staple"
+	StatusNotChecked = "not checked"
+)
+
+// defaultTTL is how long a definitive answer is cached when the responder does not announce a next update.
+const defaultTTL = time.Hour
+
+// maxResponseSize bounds OCSP responses, CRLs and issuer certificates downloaded by the Checker.
+const maxResponseSize = 10 << 20
+
+var log = logging.SetupLogging()
+
+// cachedStatus is a definitive revocation answer that is valid until expires.
+type cachedStatus struct {
+	status  string
+	expires time.Time
+}
+
+// cachedCRL is a downloaded revocation list that is valid until expires.
+type cachedCRL struct {
+	list    *x509.RevocationList
+	expires time.Time
+}
+
+// Checker performs OCSP and CRL revocation checks and caches their results.
+// Network and parsing failures are reported as StatusUnknown and are never cached.
+type Checker struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	ocsp    map[string]cachedStatus
+	crls    map[string]cachedCRL
+	issuers map[string]*x509.Certificate
+}
+
+// NewChecker returns a Checker that uses client for OCSP, CRL and AIA requests.
+func NewChecker(client *http.Client) *Checker {
+	return &Checker{
+		client:  client,
+		ocsp:    make(map[string]cachedStatus),
+		crls:    make(map[string]cachedCRL),
+		issuers: make(map[string]*x509.Certificate),
+	}
+}
+
+// CheckStaple inspects an OCSP response stapled to a TLS handshake. When issuer is nil the response
+// signature cannot be verified, so the status is reported as unknown.
+func (c *Checker) CheckStaple(staple []byte, leaf, issuer *x509.Certificate) string {
+	if len(staple) == 0 {
+		return StatusNoStaple
+	}
+	if issuer == nil {
+		return StatusUnknown
+	}
+
+	response, err := ocsp.ParseResponseForCert(staple, leaf, issuer)
+	if err != nil {
+		log.Warnf("Failed to parse stapled OCSP response for %s: %v", leaf.Subject.CommonName, err)
+		return StatusUnknown
+	}
+	return ocspStatus(response.Status)
+}
+
+// Check determines the revocation status of leaf using OCSP first and the CRL distribution points
+// second. When issuer is nil it is downloaded from the certificate's AIA extension.
+func (c *Checker) Check(ctx context.Context, leaf, issuer *x509.Certificate) string {
+	if issuer == nil {
+		var err error
+		issuer, err = c.fetchIssuer(ctx, leaf)
+		if err != nil {
+			log.Debugf("Issuer of %s unavailable: %v", leaf.Subject.CommonName, err)
+		}
+	}
+
+	if issuer != nil && len(leaf.OCSPServer) > 0 {
+		status, err := c.checkOCSP(ctx, leaf, issuer)
+		if err == nil {
+			return status
+		}
+		log.Warnf("OCSP check of %s failed: %v", leaf.Subject.CommonName, err)
+	}
+
+	// A CRL that cannot be verified against the issuer could be spoofed, since it is usually fetched
+	// over plain HTTP
+	if issuer == nil {
+		return StatusUnknown
+	}
+	for _, crlURL := range leaf.CRLDistributionPoints {
+		list, err := c.fetchCRL(ctx, crlURL, leaf, issuer)
+		if err != nil {
+			log.Warnf("CRL check of %s against %s failed: %v", leaf.Subject.CommonName, crlURL, err)
+			continue
+		}
+		for _, entry := range list.RevokedCertificateEntries {
+			if entry.SerialNumber.Cmp(leaf.SerialNumber) == 0 {
+				return StatusRevoked
+			}
+		}
+		return StatusGood
+	}
+
+	return StatusUnknown
+}
+
+// checkOCSP queries the first OCSP responder of leaf, using the cache when possible.
+func (c *Checker) checkOCSP(ctx context.Context, leaf, issuer *x509.Certificate) (string, error) {
+	key := hex.EncodeToString(issuer.RawSubjectPublicKeyInfo) + "/" + leaf.SerialNumber.String()
+	c.mu.Lock()
+	cached, ok := c.ocsp[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.status, nil
+	}
+
+	request, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return "", err
+	}
+	body, err := c.fetch(ctx, http.MethodPost, leaf.OCSPServer[0], "application/ocsp-request", request)
+	if err != nil {
+		return "", err
+	}
+	response, err := ocsp.ParseResponseForCert(body, leaf, issuer)
+	if err != nil {
+		return "", err
+	}
+
+	status := ocspStatus(response.Status)
+	if status == StatusUnknown {
+		return status, nil
+	}
+	c.mu.Lock()
+	c.ocsp[key] = cachedStatus{status: status, expires: expiry(response.NextUpdate)}
+	c.mu.Unlock()
+	return status, nil
+}
+
+// fetchCRL downloads and parses the CRL at crlURL and verifies that it was issued and signed by the
+// issuer of leaf.
+func (c *Checker) fetchCRL(ctx context.Context, crlURL string, leaf, issuer *x509.Certificate) (*x509.RevocationList, error) {
+	key := hex.EncodeToString(issuer.RawSubjectPublicKeyInfo) + "/" + crlURL
+	c.mu.Lock()
+	cached, ok := c.crls[key]
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.list, nil
+	}
+
+	body, err := c.fetch(ctx, http.MethodGet, crlURL, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(body); block != nil {
+		body = block.Bytes
+	}
+	list, err := x509.ParseRevocationList(body)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(list.RawIssuer, leaf.RawIssuer) {
+		return nil, fmt.Errorf("CRL issued by %s, not by the issuer of the certificate", list.Issuer)
+	}
+	if err := list.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("invalid CRL signature: %w", err)
+	}
+
+	c.mu.Lock()
+	c.crls[key] = cachedCRL{list: list, expires: expiry(list.NextUpdate)}
+	c.mu.Unlock()
+	return list, nil
+}
+
+// fetchIssuer downloads the issuer of leaf from its AIA caIssuers URLs.
+func (c *Checker) fetchIssuer(ctx context.Context, leaf *x509.Certificate) (*x509.Certificate, error) {
+	for _, issuerURL := range leaf.IssuingCertificateURL {
+		c.mu.Lock()
+		issuer, ok := c.issuers[issuerURL]
+		c.mu.Unlock()
+		if ok {
+			return issuer, nil
+		}
+
+		body, err := c.fetch(ctx, http.MethodGet, issuerURL, "", nil)
+		if err != nil {
+			return nil, err
+		}
+		if block, _ := pem.Decode(body); block != nil {
+			body = block.Bytes
+		}
+		issuer, err = x509.ParseCertificate(body)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.issuers[issuerURL] = issuer
+		c.mu.Unlock()
+		return issuer, nil
+	}
+	return nil, errors.New("certificate has no caIssuers URL")
+}
+
+// fetch performs an HTTP request and returns the response body.
+func (c *Checker) fetch(ctx context.Context, method, url, contentType string, payload []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned %s", method, url, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+}
+
+// ocspStatus maps an OCSP certificate status to a revocation status.
+func ocspStatus(status int) string {
+	switch status {
+	case ocsp.Good:
+		return StatusGood
+	case ocsp.Revoked:
+		return StatusRevoked
+	default:
+		return StatusUnknown
+	}
+}
+
+// expiry returns nextUpdate, or the default cache lifetime when the responder did not set one.
+func expiry(nextUpdate time.Time) time.Time {
+	if nextUpdate.IsZero() {
+		return time.Now().Add(defaultTTL)
+	}
+	return nextUpdate
+}
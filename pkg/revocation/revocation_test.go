@@ -0,0 +1,222 @@
+package revocation
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// testCA is a certificate authority that signs leaves, OCSP responses and CRLs for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testCA{cert: cert, key: key}
+}
+
+// leaf issues a certificate that points at the given OCSP responder and CRL distribution point.
+func (ca testCA) leaf(t *testing.T, serial int64, ocspURL, crlURL string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "leaf.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(12 * time.Hour),
+	}
+	if ocspURL != "" {
+		template.OCSPServer = []string{ocspURL}
+	}
+	if crlURL != "" {
+		template.CRLDistributionPoints = []string{crlURL}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// crl returns a CRL signed by the CA that revokes serials.
+func (ca testCA) crl(t *testing.T, serials ...int64) []byte {
+	t.Helper()
+	template := &x509.RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, serial := range serials {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now().Add(-time.Minute),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// ocspResponder serves OCSP responses signed by signer, reporting the serials in revoked as revoked.
+func ocspResponder(t *testing.T, ca testCA, signer testCA, revoked map[int64]bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request, err := ocsp.ParseRequest(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: request.SerialNumber,
+			ThisUpdate:   time.Now().Add(-time.Minute),
+			NextUpdate:   time.Now().Add(time.Hour),
+		}
+		if revoked[request.SerialNumber.Int64()] {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = time.Now().Add(-time.Minute)
+		}
+		response, err := ocsp.CreateResponse(ca.cert, signer.cert, template, signer.key)
+		if err != nil {
+			t.Errorf("creating OCSP response: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		_, _ = w.Write(response)
+	}))
+}
+
+// crlServer serves der as a CRL.
+func crlServer(der []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(der)
+	}))
+}
+
+func TestCheckOCSP(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	responder := ocspResponder(t, ca, ca, map[int64]bool{3: true})
+	defer responder.Close()
+
+	checker := NewChecker(responder.Client())
+	ctx := context.Background()
+	if status := checker.Check(ctx, ca.leaf(t, 2, responder.URL, ""), ca.cert); status != StatusGood {
+		t.Errorf("good certificate: got %q, want %q", status, StatusGood)
+	}
+	if status := checker.Check(ctx, ca.leaf(t, 3, responder.URL, ""), ca.cert); status != StatusRevoked {
+		t.Errorf("revoked certificate: got %q, want %q", status, StatusRevoked)
+	}
+}
+
+func TestCheckOCSPWrongSigner(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	responder := ocspResponder(t, ca, newTestCA(t, "Other CA"), nil)
+	defer responder.Close()
+
+	checker := NewChecker(responder.Client())
+	if status := checker.Check(context.Background(), ca.leaf(t, 2, responder.URL, ""), ca.cert); status != StatusUnknown {
+		t.Errorf("got %q, want %q", status, StatusUnknown)
+	}
+}
+
+func TestCheckCRL(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	other := newTestCA(t, "Other CA")
+
+	tests := []struct {
+		name   string
+		crl    []byte
+		issuer *x509.Certificate
+		want   string
+	}{
+		{name: "not revoked", crl: ca.crl(t, 7), issuer: ca.cert, want: StatusGood},
+		{name: "revoked", crl: ca.crl(t, 5), issuer: ca.cert, want: StatusRevoked},
+		{name: "unknown issuer", crl: ca.crl(t, 5), issuer: nil, want: StatusUnknown},
+		{name: "CRL of another CA", crl: other.crl(t), issuer: ca.cert, want: StatusUnknown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := crlServer(test.crl)
+			defer server.Close()
+
+			checker := NewChecker(server.Client())
+			leaf := ca.leaf(t, 5, "", server.URL)
+			if status := checker.Check(context.Background(), leaf, test.issuer); status != test.want {
+				t.Errorf("got %q, want %q", status, test.want)
+			}
+		})
+	}
+}
+
+func TestCheckStaple(t *testing.T) {
+	ca := newTestCA(t, "Test CA")
+	leaf := ca.leaf(t, 2, "", "")
+	staple, err := ocsp.CreateResponse(ca.cert, ca.cert, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: leaf.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(time.Hour),
+	}, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checker := NewChecker(http.DefaultClient)
+	if status := checker.CheckStaple(staple, leaf, ca.cert); status != StatusGood {
+		t.Errorf("verified staple: got %q, want %q", status, StatusGood)
+	}
+	if status := checker.CheckStaple(staple, leaf, nil); status != StatusUnknown {
+		t.Errorf("unverifiable staple: got %q, want %q", status, StatusUnknown)
+	}
+	if status := checker.CheckStaple(nil, leaf, ca.cert); status != StatusNoStaple {
+		t.Errorf("missing staple: got %q, want %q", status, StatusNoStaple)
+	}
+}
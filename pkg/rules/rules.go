@@ -0,0 +1,207 @@
+// pkg/rules/rules.go
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/coordinator"
+)
+
+// ExpiryRecord is the recording rule holding the seconds left before each certificate expires.
+const ExpiryRecord = "kubecertwatch:certificate_expiry_seconds"
+
+// RuleFile is a Prometheus rule file. Its groups are also the spec of a PrometheusRule.
+type RuleFile struct {
+	Groups []Group `json:"groups"`
+}
+
+// Group is a named group of rules evaluated together.
+type Group struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a recording or alerting rule.
+type Rule struct {
+	Record      string            `json:"record,omitempty"`
+	Alert       string            `json:"alert,omitempty"`
+	Expr        string            `json:"expr"`
+	For         string            `json:"for,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// objectMetric describes the per-object gauges of one source of ExpiryRecord.
+type objectMetric struct {
+	source    string
+	kind      string // kind of the object, empty when the gauges carry their own kind label
+	nameLabel string // label holding the name of the object
+	notAfter  string
+	status    string // status gauge, empty when the status does not follow the expiry thresholds
+}
+
+var objectMetrics = []objectMetric{
+	{"secret", "Secret", "secret", "tls_secret_not_after_timestamp_seconds", "tls_secret_status"},
+	{"cert-manager", "Certificate", "certificate", "cert_manager_certificate_not_after_timestamp_seconds", ""},
+	{"webhook", "", "name", "webhook_ca_bundle_not_after_timestamp_seconds", "webhook_ca_bundle_status"},
+	{"gateway", "Gateway", "gateway", "gateway_certificate_not_after_timestamp_seconds", "gateway_certificate_status"},
+	{"route", "Route", "route", "route_certificate_not_after_timestamp_seconds", "route_certificate_status"},
+	{"service", "Service", "service", "service_certificate_not_after_timestamp_seconds", "service_probe_status"},
+	{"external", "External", "target", "external_endpoint_certificate_not_after_timestamp_seconds", "external_endpoint_status"},
+}
+
+// objectLabels adds the kind and name labels of object_silenced to the series of expr.
+func (m objectMetric) objectLabels(expr string) string {
+	if m.nameLabel != "name" {
+		expr = fmt.Sprintf(`label_replace(%s, "name", "$1", "%s", "(.*)")`, expr, m.nameLabel)
+	}
+	if m.kind != "" {
+		expr = fmt.Sprintf(`label_replace(%s, "kind", "%s", "", "")`, expr, m.kind)
+	}
+	return expr
+}
+
+// Generate returns the recommended recording and alerting rules for the configured metric prefix,
+// expiry threshold and schedule.
+func Generate() RuleFile {
+	prefix := config.Current().MetricsPrefix
+	warning := config.Current().ExpiryWarningDays * 24 * 60 * 60
+	maxAge := int(coordinator.MaxRunAge().Seconds())
+
+	// Series of silenced objects are dropped by matching the cluster, kind, namespace and name labels
+	silenced := fmt.Sprintf("unless on (cluster, kind, namespace, name) %sobject_silenced", prefix)
+
+	var expiry, soon, critical []string
+	for _, m := range objectMetrics {
+		expiry = append(expiry, fmt.Sprintf(`label_replace(%s, "source", "%s", "", "")`, m.objectLabels(prefix+m.notAfter+" - time()"), m.source))
+		if m.status != "" {
+			soon = append(soon, m.objectLabels(fmt.Sprintf(`%s%s{status="expiring soon"} == 1`, prefix, m.status)))
+			critical = append(critical, m.objectLabels(fmt.Sprintf(`%s%s{status="critical"} == 1`, prefix, m.status)))
+		}
+	}
+
+	recording := Group{
+		Name: "kubecertwatch.rules",
+		Rules: []Rule{
+			{Record: ExpiryRecord, Expr: strings.Join(expiry, "\nor\n")},
+			{Record: "kubecertwatch:certificates_expiring:count", Expr: fmt.Sprintf("count by (cluster, source) (%s < %d)", ExpiryRecord, warning)},
+		},
+	}
+
+	alerts := Group{
+		Name: "kubecertwatch.alerts",
+		Rules: []Rule{
+			{
+				Alert:  "KubeCertWatchCertificateExpiringSoon",
+				Expr:   fmt.Sprintf("(\n%s\n) %s", strings.Join(soon, "\nor\n"), silenced),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "A certificate in cluster {{ $labels.cluster }} is within the warningDays of its CertWatchPolicy.",
+					"description": "The certificate expires soon. Labels: {{ $labels }}",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchCertificateCritical",
+				Expr:   fmt.Sprintf("(\n%s\n) %s", strings.Join(critical, "\nor\n"), silenced),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "A certificate in cluster {{ $labels.cluster }} is within the criticalDays of its CertWatchPolicy.",
+					"description": "The certificate expires soon. Labels: {{ $labels }}",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchCertificateExpired",
+				Expr:   fmt.Sprintf("%s <= 0 %s", ExpiryRecord, silenced),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary":     "A {{ $labels.source }} certificate in cluster {{ $labels.cluster }} has expired.",
+					"description": "The certificate is no longer valid. Labels: {{ $labels }}",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchCertificateRevoked",
+				Expr:   fmt.Sprintf("%scertificate_revoked == 1", prefix),
+				Labels: map[string]string{"severity": "critical"},
+				Annotations: map[string]string{
+					"summary": "Certificate {{ $labels.namespace }}/{{ $labels.name }} in cluster {{ $labels.cluster }} has been revoked.",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchCertManagerCertificateNotReady",
+				Expr:   fmt.Sprintf(`%scert_manager_certificate_status{status="not ready"} == 1`, prefix),
+				For:    "30m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": "cert-manager Certificate {{ $labels.namespace }}/{{ $labels.certificate }} in cluster {{ $labels.cluster }} is not ready: {{ $labels.reason }}.",
+				},
+			},
+			{
+				Alert: "KubeCertWatchTLSProbeFailing",
+				Expr: fmt.Sprintf("%singress_probe_success == 0 or %sgateway_probe_success == 0 or %sexternal_endpoint_probe_success == 0",
+					prefix, prefix, prefix),
+				For:    "30m",
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "A TLS endpoint in cluster {{ $labels.cluster }} cannot be probed.",
+					"description": "The TLS handshake with the endpoint fails. Labels: {{ $labels }}",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchWeakTLSConfiguration",
+				Expr:   fmt.Sprintf("%stls_probe_findings == 1", prefix),
+				For:    "1h",
+				Labels: map[string]string{"severity": "info"},
+				Annotations: map[string]string{
+					"summary": "{{ $labels.address }} in cluster {{ $labels.cluster }}: {{ $labels.finding }}.",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchCheckErrors",
+				Expr:   fmt.Sprintf("increase(%scertificate_check_errors_total[1h]) > 0", prefix),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary": "The {{ $labels.check_type }} check in cluster {{ $labels.cluster }} failed with {{ $labels.error_type }}.",
+				},
+			},
+			{
+				Alert:  "KubeCertWatchChecksStale",
+				Expr:   fmt.Sprintf(`time() - %slast_check_time{check_name=~"%s"} > %d`, prefix, scheduledTimeNames(), maxAge),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "The {{ $labels.check_name }} check has not run in cluster {{ $labels.cluster }} for {{ $value | humanizeDuration }}.",
+					"description": "Certificate results are out of date. Check the logs and /readyz of KubeCertWatch.",
+				},
+			},
+		},
+	}
+	if config.Current().HubEnabled {
+		alerts.Rules = append(alerts.Rules, Rule{
+			Alert:  "KubeCertWatchAgentDown",
+			Expr:   fmt.Sprintf("%shub_agent_up == 0", prefix),
+			Labels: map[string]string{"severity": "warning"},
+			Annotations: map[string]string{
+				"summary": "Agent {{ $labels.agent }} stopped reporting to the hub in cluster {{ $labels.cluster }}.",
+			},
+		})
+	}
+
+	return RuleFile{Groups: []Group{recording, alerts}}
+}
+
+// scheduledTimeNames returns a regular expression matching the last_check_time names of the scheduled
+// checks. Checks that only run on request would otherwise look stale between requests.
+func scheduledTimeNames() string {
+	scheduled := map[string]bool{}
+	for _, name := range coordinator.ScheduledChecks() {
+		scheduled[name] = true
+	}
+	var names []string
+	for _, check := range coordinator.Checks() {
+		if scheduled[check.Name] {
+			names = append(names, check.TimeName)
+		}
+	}
+	return strings.Join(names, "|")
+}
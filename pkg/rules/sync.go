@@ -0,0 +1,115 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	log = logging.SetupLogging()
+
+	prometheusRuleGVR = schema.GroupVersionResource{Group: "monitoring.coreos.com", Version: "v1", Resource: "prometheusrules"}
+)
+
+// SyncPrometheusRule creates or updates the PrometheusRule configured by PROMETHEUS_RULE_* with the
+// generated rules. It does nothing when the Prometheus Operator CRD is not installed.
+func SyncPrometheusRule(ctx context.Context) error {
+	cluster, err := k8s.LocalCluster()
+	if err != nil {
+		return err
+	}
+
+	installed, err := prometheusRuleInstalled(cluster)
+	if err != nil {
+		return err
+	}
+	if !installed {
+		log.Println("PrometheusRule CRD is not installed. Skipping PrometheusRule sync.")
+		return nil
+	}
+
+	namespace := config.Current().PrometheusRuleNamespace
+	if namespace == "" {
+		namespace = k8s.PodNamespace()
+	}
+	desired, err := prometheusRule(namespace)
+	if err != nil {
+		return err
+	}
+
+	dynamicClient, err := cluster.DynamicClient()
+	if err != nil {
+		return err
+	}
+	client := dynamicClient.Resource(prometheusRuleGVR).Namespace(namespace)
+
+	existing, err := client.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := client.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("creating PrometheusRule %s/%s: %w", namespace, desired.GetName(), err)
+		}
+		log.Printf("Created PrometheusRule %s/%s", namespace, desired.GetName())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("getting PrometheusRule %s/%s: %w", namespace, desired.GetName(), err)
+	}
+
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := client.Update(ctx, desired, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("updating PrometheusRule %s/%s: %w", namespace, desired.GetName(), err)
+	}
+	log.Printf("Updated PrometheusRule %s/%s", namespace, desired.GetName())
+	return nil
+}
+
+// prometheusRuleInstalled reports whether the API server serves monitoring.coreos.com/v1 PrometheusRules.
+func prometheusRuleInstalled(cluster k8s.Cluster) (bool, error) {
+	resources, err := cluster.Clientset.Discovery().ServerResourcesForGroupVersion(prometheusRuleGVR.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == prometheusRuleGVR.Resource {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// prometheusRule builds the PrometheusRule object holding the generated rules.
+func prometheusRule(namespace string) (*unstructured.Unstructured, error) {
+	data, err := json.Marshal(Generate())
+	if err != nil {
+		return nil, err
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{"app.kubernetes.io/managed-by": "kubecertwatch"}
+	for name, value := range config.Current().PrometheusRuleLabels {
+		labels[name] = value
+	}
+
+	rule := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	rule.SetAPIVersion(prometheusRuleGVR.GroupVersion().String())
+	rule.SetKind("PrometheusRule")
+	rule.SetName(config.Current().PrometheusRuleName)
+	rule.SetNamespace(namespace)
+	rule.SetLabels(labels)
+	return rule, nil
+}
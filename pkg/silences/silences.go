@@ -0,0 +1,200 @@
+// pkg/silences/silences.go
+package silences
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+)
+
+// Annotations that silence the object carrying them. IgnoreUntilAnnotation holds a date such as
+// 2025-12-31, which silences the object until the end of that day in UTC, or an RFC 3339 time.
+const (
+	IgnoreUntilAnnotation  = "kubecertwatch.io/ignore-until"
+	IgnoreReasonAnnotation = "kubecertwatch.io/ignore-reason"
+)
+
+// AnnotationID is the ID of silences set through IgnoreUntilAnnotation.
+const AnnotationID = "annotation"
+
+// Labels are the object labels that silences can match on.
+var Labels = []string{"cluster", "namespace", "kind", "name", "owner"}
+
+var (
+	log = logging.SetupLogging()
+
+	silenceLock sync.RWMutex
+	silences    []Silence
+	listeners   []func()
+)
+
+// Silence suppresses the notifications of the objects it matches until it expires. Silenced objects
+// stay on the status pages and in the metrics, marked as silenced.
+type Silence struct {
+	ID        string            `json:"id"`
+	Match     map[string]string `json:"match,omitempty"`
+	MatchRE   map[string]string `json:"matchRE,omitempty"`
+	Reason    string            `json:"reason"`
+	Author    string            `json:"author"`
+	CreatedAt time.Time         `json:"createdAt"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// Object identifies a monitored object that silences are matched against. Cluster-scoped objects and
+// external endpoints have no namespace.
+type Object struct {
+	Cluster   string
+	Namespace string
+	Kind      string
+	Name      string
+	Owner     string
+}
+
+// labels returns the labels silences match on.
+func (o Object) labels() map[string]string {
+	return map[string]string{
+		"cluster":   o.Cluster,
+		"namespace": o.Namespace,
+		"kind":      o.Kind,
+		"name":      o.Name,
+		"owner":     o.Owner,
+	}
+}
+
+// Active reports whether the silence is in effect at now.
+func (s Silence) Active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// Matches reports whether obj satisfies every matcher of the silence. Regular expressions are anchored
+// and kinds are compared case-insensitively.
+func (s Silence) Matches(obj Object) bool {
+	labels := obj.labels()
+	for name, value := range s.Match {
+		if labels[name] != value && !(name == "kind" && strings.EqualFold(labels[name], value)) {
+			return false
+		}
+	}
+	for name, pattern := range s.MatchRE {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil || !re.MatchString(labels[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate checks a silence submitted through the API.
+func (s Silence) Validate() error {
+	if len(s.Match) == 0 && len(s.MatchRE) == 0 {
+		return fmt.Errorf("a silence needs at least one matcher")
+	}
+	for name := range s.Match {
+		if !isLabel(name) {
+			return fmt.Errorf("cannot match on %q (supported: %s)", name, strings.Join(Labels, ", "))
+		}
+	}
+	for name, pattern := range s.MatchRE {
+		if !isLabel(name) {
+			return fmt.Errorf("cannot match on %q (supported: %s)", name, strings.Join(Labels, ", "))
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid matchRE for %s: %v", name, err)
+		}
+	}
+	if strings.TrimSpace(s.Reason) == "" {
+		return fmt.Errorf("reason is required")
+	}
+	if strings.TrimSpace(s.Author) == "" {
+		return fmt.Errorf("author is required")
+	}
+	if !s.Active(time.Now()) {
+		return fmt.Errorf("expiresAt must be in the future")
+	}
+	return nil
+}
+
+// Describe returns a one-line description of the silence for the status pages.
+func (s Silence) Describe() string {
+	return fmt.Sprintf("until %s by %s: %s", s.ExpiresAt.UTC().Format(time.RFC3339), s.Author, s.Reason)
+}
+
+// isLabel reports whether silences can match on the object label name.
+func isLabel(name string) bool {
+	for _, label := range Labels {
+		if name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// FromAnnotations returns the silence set by the IgnoreUntilAnnotation of an object, or nil when the
+// annotation is absent.
+func FromAnnotations(annotations map[string]string) (*Silence, error) {
+	value, ok := annotations[IgnoreUntilAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		day, dayErr := time.Parse("2006-01-02", value)
+		if dayErr != nil {
+			return nil, fmt.Errorf("invalid %s %q: expected a date such as 2025-12-31 or an RFC 3339 time", IgnoreUntilAnnotation, value)
+		}
+		expiresAt = day.Add(24 * time.Hour)
+	}
+	reason := annotations[IgnoreReasonAnnotation]
+	if reason == "" {
+		reason = IgnoreUntilAnnotation + " annotation"
+	}
+	return &Silence{ID: AnnotationID, Reason: reason, Author: "annotation", ExpiresAt: expiresAt}, nil
+}
+
+// Find returns the silence in effect for obj: its annotation silence, otherwise the first active
+// silence that matches it.
+func Find(obj Object, annotation *Silence) (Silence, bool) {
+	now := time.Now()
+	if annotation != nil && annotation.Active(now) {
+		return *annotation, true
+	}
+
+	silenceLock.RLock()
+	defer silenceLock.RUnlock()
+	for _, silence := range silences {
+		if silence.Active(now) && silence.Matches(obj) {
+			return silence, true
+		}
+	}
+	return Silence{}, false
+}
+
+// List returns the known silences, including those that expired recently.
+func List() []Silence {
+	silenceLock.RLock()
+	defer silenceLock.RUnlock()
+	return append([]Silence{}, silences...)
+}
+
+// OnChange registers fn to be called whenever the silences change.
+func OnChange(fn func()) {
+	silenceLock.Lock()
+	defer silenceLock.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// setSilences replaces the known silences and notifies the listeners.
+func setSilences(updated []Silence) {
+	silenceLock.Lock()
+	silences = updated
+	notify := append([]func(){}, listeners...)
+	silenceLock.Unlock()
+
+	for _, fn := range notify {
+		fn()
+	}
+}
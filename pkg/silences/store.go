@@ -0,0 +1,221 @@
+// pkg/silences/store.go
+package silences
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/supporttools/KubeCertWatch/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// ConfigMapName is the ConfigMap in the pod namespace that holds the silences.
+	ConfigMapName = "kubecertwatch-silences"
+	// configMapKey is the key of the JSON-encoded silences in the ConfigMap.
+	configMapKey = "silences.json"
+	// retention is how long expired silences are kept.
+	retention = 7 * 24 * time.Hour
+	// syncTimeout bounds the initial load of the silences.
+	syncTimeout = 30 * time.Second
+)
+
+var (
+	// ErrNotFound is returned for silences that do not exist.
+	ErrNotFound = errors.New("silence not found")
+	// ErrUnavailable is returned when silences cannot be stored.
+	ErrUnavailable = errors.New("silences are not available")
+
+	// storeLock serializes loads and writes and guards the client used for writes.
+	storeLock sync.Mutex
+	client    kubernetes.Interface
+	namespace string
+)
+
+// Watch loads the silences from their ConfigMap in the local cluster and keeps them up to date until
+// ctx is done, so that every replica sees the silences created through any of them.
+func Watch(ctx context.Context) error {
+	cluster, err := k8s.LocalCluster()
+	if err != nil {
+		return err
+	}
+
+	storeLock.Lock()
+	client, namespace = cluster.Clientset, k8s.PodNamespace()
+	storeLock.Unlock()
+
+	factory := informers.NewSharedInformerFactoryWithOptions(cluster.Clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = "metadata.name=" + ConfigMapName
+		}),
+	)
+	informer := factory.Core().V1().ConfigMaps().Informer()
+	reload := func() {
+		load(informer.GetStore())
+	}
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { reload() },
+		UpdateFunc: func(interface{}, interface{}) { reload() },
+		DeleteFunc: func(interface{}) { reload() },
+	}); err != nil {
+		return err
+	}
+	factory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), informer.HasSynced) {
+		return errors.New("timed out loading silences")
+	}
+	log.Printf("Loaded %d silences.", load(informer.GetStore()))
+	return nil
+}
+
+// load activates the silences held by the informer store and returns their number.
+func load(store cache.Store) int {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+
+	var loaded []Silence
+	for _, item := range store.List() {
+		configMap, ok := item.(*corev1.ConfigMap)
+		if !ok {
+			continue
+		}
+		decoded, err := decode(configMap)
+		if err != nil {
+			log.Warnf("Ignoring invalid ConfigMap %s/%s: %v", configMap.Namespace, configMap.Name, err)
+			continue
+		}
+		loaded = decoded
+	}
+	setSilences(loaded)
+	return len(loaded)
+}
+
+// Create stores silence and returns it with its ID and creation time.
+func Create(ctx context.Context, silence Silence) (Silence, error) {
+	silence.ID = newID()
+	silence.CreatedAt = time.Now().UTC()
+	err := update(ctx, func(current []Silence) ([]Silence, error) {
+		return append(current, silence), nil
+	})
+	return silence, err
+}
+
+// Expire ends the silence with the given ID now. Silences that already expired are returned unchanged.
+func Expire(ctx context.Context, id string) (Silence, error) {
+	var expired Silence
+	err := update(ctx, func(current []Silence) ([]Silence, error) {
+		for i := range current {
+			if current[i].ID != id {
+				continue
+			}
+			now := time.Now().UTC()
+			if current[i].Active(now) {
+				current[i].ExpiresAt = now
+			}
+			expired = current[i]
+			return current, nil
+		}
+		return nil, ErrNotFound
+	})
+	return expired, err
+}
+
+// update applies modify to the stored silences, dropping those that expired more than retention ago.
+func update(ctx context.Context, modify func([]Silence) ([]Silence, error)) error {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	if client == nil {
+		return ErrUnavailable
+	}
+
+	var updated []Silence
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, ConfigMapName, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			configMap = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigMapName,
+				Namespace: namespace,
+				Labels:    map[string]string{"app.kubernetes.io/managed-by": "kubecertwatch"},
+			}}
+		} else if err != nil {
+			return err
+		}
+
+		current, err := decode(configMap)
+		if err != nil {
+			return err
+		}
+		if updated, err = modify(prune(current)); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(updated, "", "  ")
+		if err != nil {
+			return err
+		}
+		configMap.Data = map[string]string{configMapKey: string(data)}
+
+		if create {
+			_, err = client.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
+		} else {
+			_, err = client.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Activate the change at once rather than when the informer catches up
+	setSilences(updated)
+	return nil
+}
+
+// decode reads the silences stored in configMap.
+func decode(configMap *corev1.ConfigMap) ([]Silence, error) {
+	data := configMap.Data[configMapKey]
+	if data == "" {
+		return nil, nil
+	}
+	var stored []Silence
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// prune drops the silences that expired more than retention ago.
+func prune(stored []Silence) []Silence {
+	cutoff := time.Now().Add(-retention)
+	kept := stored[:0]
+	for _, silence := range stored {
+		if silence.ExpiresAt.After(cutoff) {
+			kept = append(kept, silence)
+		}
+	}
+	return kept
+}
+
+// newID returns a random identifier for a silence.
+func newID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
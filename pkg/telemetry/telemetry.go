@@ -0,0 +1,169 @@
+// pkg/telemetry/telemetry.go
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path"
+
+	"github.com/supporttools/KubeCertWatch/pkg/config"
+	"github.com/supporttools/KubeCertWatch/pkg/logging"
+	"github.com/supporttools/KubeCertWatch/pkg/metrics"
+	"github.com/supporttools/KubeCertWatch/pkg/version"
+	promexporter "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the spans created by KubeCertWatch.
+const instrumentationName = "github.com/supporttools/KubeCertWatch"
+
+var log = logging.SetupLogging()
+
+// Start configures OTLP export of the Prometheus metrics and of check run traces as set by OTLP_*.
+// Without OTLP_ENDPOINT nothing is exported and spans are discarded. The returned function flushes
+// and stops the exporters.
+func Start(ctx context.Context) (func(context.Context) error, error) {
+	if config.Current().OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := url.Parse(config.Current().OTLPEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	insecure := endpoint.Scheme == "http"
+
+	res, err := resource.New(ctx,
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName("kubecertwatch"),
+			semconv.ServiceVersion(version.Version),
+			semconv.K8SClusterName(config.Current().ClusterName),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		log.Warnf("OpenTelemetry export failed: %v", err)
+	}))
+
+	var shutdowns []func(context.Context) error
+	shutdown := func(ctx context.Context) error {
+		var errs []error
+		for _, fn := range shutdowns {
+			errs = append(errs, fn(ctx))
+		}
+		return errors.Join(errs...)
+	}
+
+	if config.Current().OTLPTraces {
+		exporter, err := newTraceExporter(ctx, endpoint, insecure)
+		if err != nil {
+			return nil, err
+		}
+		provider := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exporter),
+			sdktrace.WithResource(res),
+		)
+		otel.SetTracerProvider(provider)
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+
+	if config.Current().OTLPMetrics {
+		exporter, err := newMetricExporter(ctx, endpoint, insecure)
+		if err != nil {
+			_ = shutdown(ctx)
+			return nil, err
+		}
+		reader := sdkmetric.NewPeriodicReader(exporter,
+			sdkmetric.WithInterval(config.Current().OTLPExportInterval),
+			sdkmetric.WithProducer(promexporter.NewMetricProducer(promexporter.WithGatherer(metrics.Gatherer()))),
+		)
+		provider := sdkmetric.NewMeterProvider(
+			sdkmetric.WithReader(reader),
+			sdkmetric.WithResource(res),
+		)
+		shutdowns = append(shutdowns, provider.Shutdown)
+	}
+
+	log.Printf("Exporting OpenTelemetry data to %s over %s (metrics: %t, traces: %t)",
+		endpoint.Redacted(), config.Current().OTLPProtocol, config.Current().OTLPMetrics, config.Current().OTLPTraces)
+	return shutdown, nil
+}
+
+// newTraceExporter creates the OTLP span exporter for the configured protocol.
+func newTraceExporter(ctx context.Context, endpoint *url.URL, insecure bool) (sdktrace.SpanExporter, error) {
+	if config.Current().OTLPProtocol == "http" {
+		options := []otlptracehttp.Option{
+			otlptracehttp.WithEndpoint(endpoint.Host),
+			otlptracehttp.WithURLPath(path.Join("/", endpoint.Path, "v1/traces")),
+			otlptracehttp.WithHeaders(config.Current().OTLPHeaders),
+		}
+		if insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, options...)
+	}
+
+	options := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(endpoint.Host),
+		otlptracegrpc.WithHeaders(config.Current().OTLPHeaders),
+	}
+	if insecure {
+		options = append(options, otlptracegrpc.WithInsecure())
+	}
+	return otlptracegrpc.New(ctx, options...)
+}
+
+// newMetricExporter creates the OTLP metric exporter for the configured protocol.
+func newMetricExporter(ctx context.Context, endpoint *url.URL, insecure bool) (sdkmetric.Exporter, error) {
+	if config.Current().OTLPProtocol == "http" {
+		options := []otlpmetrichttp.Option{
+			otlpmetrichttp.WithEndpoint(endpoint.Host),
+			otlpmetrichttp.WithURLPath(path.Join("/", endpoint.Path, "v1/metrics")),
+			otlpmetrichttp.WithHeaders(config.Current().OTLPHeaders),
+		}
+		if insecure {
+			options = append(options, otlpmetrichttp.WithInsecure())
+		}
+		return otlpmetrichttp.New(ctx, options...)
+	}
+
+	options := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(endpoint.Host),
+		otlpmetricgrpc.WithHeaders(config.Current().OTLPHeaders),
+	}
+	if insecure {
+		options = append(options, otlpmetricgrpc.WithInsecure())
+	}
+	return otlpmetricgrpc.New(ctx, options...)
+}
+
+// StartSpan starts a span below the span in ctx. Spans are discarded unless trace export is enabled.
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records err, if any, on span and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}